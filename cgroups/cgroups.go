@@ -18,11 +18,19 @@ var (
 	RelCgroupPath = "testdir/cgrouptest/container"
 )
 
+// Freezer states, as reported by the freezer subsystem
+const (
+	FreezerStateFrozen   = "FROZEN"
+	FreezerStateFreezing = "FREEZING"
+	FreezerStateThawed   = "THAWED"
+)
+
 // Cgroup represents interfaces for cgroup validation
 type Cgroup interface {
 	GetBlockIOData(pid int, cgPath string) (*rspec.LinuxBlockIO, error)
 	GetCPUData(pid int, cgPath string) (*rspec.LinuxCPU, error)
 	GetDevicesData(pid int, cgPath string) ([]rspec.LinuxDeviceCgroup, error)
+	GetFreezerState(pid int, cgPath string) (string, error)
 	GetHugepageLimitData(pid int, cgPath string) ([]rspec.LinuxHugepageLimit, error)
 	GetMemoryData(pid int, cgPath string) (*rspec.LinuxMemory, error)
 	GetNetworkData(pid int, cgPath string) (*rspec.LinuxNetwork, error)
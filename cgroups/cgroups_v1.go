@@ -38,6 +38,11 @@ func getDeviceID(id string) (int64, int64, error) {
 
 // GetBlockIOData gets cgroup blockio data
 func (cg *CgroupV1) GetBlockIOData(pid int, cgPath string) (*rspec.LinuxBlockIO, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	if filepath.IsAbs(cgPath) {
 		path := filepath.Join(cg.MountPath, "blkio", cgPath)
 		if _, err := os.Stat(path); err != nil {
@@ -213,6 +218,11 @@ func (cg *CgroupV1) GetBlockIOData(pid int, cgPath string) (*rspec.LinuxBlockIO,
 
 // GetCPUData gets cgroup cpus data
 func (cg *CgroupV1) GetCPUData(pid int, cgPath string) (*rspec.LinuxCPU, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	if filepath.IsAbs(cgPath) {
 		path := filepath.Join(cg.MountPath, "cpu", cgPath)
 		if _, err := os.Stat(path); err != nil {
@@ -321,6 +331,11 @@ func (cg *CgroupV1) GetCPUData(pid int, cgPath string) (*rspec.LinuxCPU, error)
 
 // GetDevicesData gets cgroup devices data
 func (cg *CgroupV1) GetDevicesData(pid int, cgPath string) ([]rspec.LinuxDeviceCgroup, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	ld := []rspec.LinuxDeviceCgroup{}
 	fileName := strings.Join([]string{"devices", "list"}, ".")
 	filePath := filepath.Join(cg.MountPath, "devices", cgPath, fileName)
@@ -372,6 +387,51 @@ func (cg *CgroupV1) GetDevicesData(pid int, cgPath string) ([]rspec.LinuxDeviceC
 	return ld, nil
 }
 
+// GetFreezerState gets cgroup freezer state
+func (cg *CgroupV1) GetFreezerState(pid int, cgPath string) (string, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.IsAbs(cgPath) {
+		path := filepath.Join(cg.MountPath, "freezer", cgPath)
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return "", specerror.NewError(specerror.CgroupsAbsPathRelToMount, fmt.Errorf("In the case of an absolute path, the runtime MUST take the path to be relative to the cgroups mount point"), rspec.Version)
+			}
+			return "", err
+		}
+	}
+	fileName := strings.Join([]string{"freezer", "state"}, ".")
+	filePath := filepath.Join(cg.MountPath, "freezer", cgPath, fileName)
+	if !filepath.IsAbs(cgPath) {
+		subPath, err := GetSubsystemPath(pid, "freezer")
+		if err != nil {
+			return "", err
+		}
+		if !strings.Contains(subPath, cgPath) {
+			return "", fmt.Errorf("cgroup subsystem %s is not mounted as expected", "freezer")
+		}
+		filePath = filepath.Join(cg.MountPath, "freezer", subPath, fileName)
+	}
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", specerror.NewError(specerror.CgroupsPathAttach, fmt.Errorf("The runtime MUST consistently attach to the same place in the cgroups hierarchy given the same value of `cgroupsPath`"), rspec.Version)
+		}
+
+		return "", err
+	}
+	state := strings.TrimSpace(string(contents))
+	switch state {
+	case FreezerStateFrozen, FreezerStateFreezing, FreezerStateThawed:
+		return state, nil
+	}
+
+	return "", fmt.Errorf("unknown freezer state %q", state)
+}
+
 func inBytes(size string) (int64, error) {
 	KiB := 1024
 	MiB := 1024 * KiB
@@ -430,6 +490,11 @@ func GetHugePageSize() ([]string, error) {
 
 // GetHugepageLimitData gets cgroup hugetlb data
 func (cg *CgroupV1) GetHugepageLimitData(pid int, cgPath string) ([]rspec.LinuxHugepageLimit, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	if filepath.IsAbs(cgPath) {
 		path := filepath.Join(cg.MountPath, "hugetlb", cgPath)
 		if _, err := os.Stat(path); err != nil {
@@ -480,6 +545,11 @@ func (cg *CgroupV1) GetHugepageLimitData(pid int, cgPath string) ([]rspec.LinuxH
 
 // GetMemoryData gets cgroup memory data
 func (cg *CgroupV1) GetMemoryData(pid int, cgPath string) (*rspec.LinuxMemory, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	if filepath.IsAbs(cgPath) {
 		path := filepath.Join(cg.MountPath, "memory", cgPath)
 		if _, err := os.Stat(path); err != nil {
@@ -575,6 +645,11 @@ func (cg *CgroupV1) GetMemoryData(pid int, cgPath string) (*rspec.LinuxMemory, e
 
 // GetNetworkData gets cgroup network data
 func (cg *CgroupV1) GetNetworkData(pid int, cgPath string) (*rspec.LinuxNetwork, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	if filepath.IsAbs(cgPath) {
 		path := filepath.Join(cg.MountPath, "net_cls", cgPath)
 		if _, err := os.Stat(path); err != nil {
@@ -646,6 +721,11 @@ func (cg *CgroupV1) GetNetworkData(pid int, cgPath string) (*rspec.LinuxNetwork,
 
 // GetPidsData gets cgroup pids data
 func (cg *CgroupV1) GetPidsData(pid int, cgPath string) (*rspec.LinuxPids, error) {
+	cgPath, err := ResolveCgroupsPath(cgPath)
+	if err != nil {
+		return nil, err
+	}
+
 	if filepath.IsAbs(cgPath) {
 		path := filepath.Join(cg.MountPath, "pids", cgPath)
 		if _, err := os.Stat(path); err != nil {
@@ -672,7 +752,12 @@ func (cg *CgroupV1) GetPidsData(pid int, cgPath string) (*rspec.LinuxPids, error
 	if err != nil {
 		return nil, err
 	}
-	res, err := strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 64)
+	value := strings.TrimSpace(string(contents))
+	if value == "max" {
+		lp.Limit = -1
+		return lp, nil
+	}
+	res, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, specerror.NewError(specerror.CgroupsPathAttach, fmt.Errorf("The runtime MUST consistently attach to the same place in the cgroups hierarchy given the same value of `cgroupsPath`"), rspec.Version)
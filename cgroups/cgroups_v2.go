@@ -26,6 +26,11 @@ func GetDevicesData(pid int, cgPath string) ([]rspec.LinuxDeviceCgroup, error) {
 	return nil, fmt.Errorf("unimplemented yet")
 }
 
+// GetFreezerState gets cgroup freezer state
+func (cg *CgroupV2) GetFreezerState(pid int, cgPath string) (string, error) {
+	return "", fmt.Errorf("unimplemented yet")
+}
+
 // GetHugepageLimitData gets cgroup hugetlb data
 func GetHugepageLimitData(pid int, cgPath string) ([]rspec.LinuxHugepageLimit, error) {
 	return nil, fmt.Errorf("unimplemented yet")
@@ -0,0 +1,112 @@
+package cgroups
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultSlice is the slice used by a systemd cgroups path which does not
+// name one.
+const defaultSlice = "system.slice"
+
+// IsSystemdCgroupsPath returns true if path has the "slice:prefix:name"
+// form used with the systemd cgroup driver.
+func IsSystemdCgroupsPath(path string) bool {
+	return !strings.HasPrefix(path, "/") && strings.Count(path, ":") == 2
+}
+
+// ExpandSlice returns the cgroup path of a systemd slice, where each dash
+// separated prefix of the name is a parent slice, e.g. "user-1000.slice"
+// is "/user.slice/user-1000.slice".
+func ExpandSlice(slice string) (string, error) {
+	const suffix = ".slice"
+	if !strings.HasSuffix(slice, suffix) || strings.Contains(slice, "/") {
+		return "", fmt.Errorf("invalid slice name %q", slice)
+	}
+	// the root slice
+	if slice == "-.slice" {
+		return "/", nil
+	}
+
+	name := strings.TrimSuffix(slice, suffix)
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") || strings.Contains(name, "--") {
+		return "", fmt.Errorf("invalid slice name %q", slice)
+	}
+
+	var expanded, prefix string
+	for _, component := range strings.Split(name, "-") {
+		prefix += component
+		expanded += "/" + prefix + suffix
+		prefix += "-"
+	}
+	return expanded, nil
+}
+
+// SystemdToCgroupfsPath translates a systemd cgroups path of the form
+// "slice:prefix:name" to the path below the cgroup mount, e.g.
+// "system.slice:runc:id" is "/system.slice/runc-id.scope".  An empty slice
+// is system.slice, and a name ending in .slice is a slice itself.
+func SystemdToCgroupfsPath(cgroupsPath string) (string, error) {
+	parts := strings.Split(cgroupsPath, ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("systemd cgroups path %q must be of the form slice:prefix:name", cgroupsPath)
+	}
+	slice, prefix, name := parts[0], parts[1], parts[2]
+	if name == "" {
+		return "", fmt.Errorf("systemd cgroups path %q has no name", cgroupsPath)
+	}
+	if slice == "" {
+		slice = defaultSlice
+	}
+
+	parent, err := ExpandSlice(slice)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasSuffix(name, ".slice") {
+		unit, err := ExpandSlice(name)
+		if err != nil {
+			return "", err
+		}
+		return path.Join(parent, path.Base(unit)), nil
+	}
+
+	unit := name + ".scope"
+	if prefix != "" {
+		unit = prefix + "-" + unit
+	}
+	return path.Join(parent, unit), nil
+}
+
+// CgroupfsToSystemdPath translates the path of a systemd scope below the
+// cgroup mount back to the "slice:prefix:name" form.  The prefix is taken
+// to end at the first dash of the scope name.
+func CgroupfsToSystemdPath(cgroupfsPath string) (string, error) {
+	dir, unit := path.Split(path.Clean(cgroupfsPath))
+	if !strings.HasSuffix(unit, ".scope") {
+		return "", fmt.Errorf("%s is not a systemd scope", cgroupfsPath)
+	}
+	slice := path.Base(dir)
+	if !strings.HasSuffix(slice, ".slice") {
+		return "", fmt.Errorf("%s is not in a systemd slice", cgroupfsPath)
+	}
+
+	name := strings.TrimSuffix(unit, ".scope")
+	prefix := ""
+	if i := strings.Index(name, "-"); i >= 0 {
+		prefix, name = name[:i], name[i+1:]
+	}
+	return strings.Join([]string{slice, prefix, name}, ":"), nil
+}
+
+// ResolveCgroupsPath returns the path below the cgroup mount for
+// linux.cgroupsPath, translating the systemd form and returning other
+// paths unchanged.
+func ResolveCgroupsPath(cgroupsPath string) (string, error) {
+	if !IsSystemdCgroupsPath(cgroupsPath) {
+		return cgroupsPath, nil
+	}
+	return SystemdToCgroupfsPath(cgroupsPath)
+}
@@ -0,0 +1,79 @@
+package cgroups
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandSlice(t *testing.T) {
+	for _, tc := range []struct {
+		slice    string
+		expanded string
+		valid    bool
+	}{
+		{"system.slice", "/system.slice", true},
+		{"user-1000.slice", "/user.slice/user-1000.slice", true},
+		{"a-b-c.slice", "/a.slice/a-b.slice/a-b-c.slice", true},
+		{"-.slice", "/", true},
+		{"system", "", false},
+		{".slice", "", false},
+		{"a--b.slice", "", false},
+		{"a-.slice", "", false},
+		{"a/b.slice", "", false},
+	} {
+		expanded, err := ExpandSlice(tc.slice)
+		if tc.valid {
+			assert.Nil(t, err, tc.slice)
+			assert.Equal(t, tc.expanded, expanded, tc.slice)
+		} else {
+			assert.NotNil(t, err, tc.slice)
+		}
+	}
+}
+
+func TestSystemdCgroupsPath(t *testing.T) {
+	for _, tc := range []struct {
+		systemd  string
+		cgroupfs string
+	}{
+		{"system.slice:runc:abc", "/system.slice/runc-abc.scope"},
+		{"user-1000.slice:crio:abc", "/user.slice/user-1000.slice/crio-abc.scope"},
+		{"machine.slice:docker:0123456789", "/machine.slice/docker-0123456789.scope"},
+	} {
+		assert.True(t, IsSystemdCgroupsPath(tc.systemd), tc.systemd)
+
+		cgroupfs, err := SystemdToCgroupfsPath(tc.systemd)
+		assert.Nil(t, err, tc.systemd)
+		assert.Equal(t, tc.cgroupfs, cgroupfs, tc.systemd)
+
+		systemd, err := CgroupfsToSystemdPath(tc.cgroupfs)
+		assert.Nil(t, err, tc.cgroupfs)
+		assert.Equal(t, tc.systemd, systemd, tc.cgroupfs)
+	}
+
+	for systemd, cgroupfs := range map[string]string{
+		":runc:abc":               "/system.slice/runc-abc.scope",
+		"system.slice::abc":       "/system.slice/abc.scope",
+		"system.slice::abc.slice": "/system.slice/abc.slice",
+	} {
+		path, err := SystemdToCgroupfsPath(systemd)
+		assert.Nil(t, err, systemd)
+		assert.Equal(t, cgroupfs, path, systemd)
+	}
+
+	for _, systemd := range []string{"system.slice:runc:", "system:runc:abc", "system.slice:runc"} {
+		_, err := SystemdToCgroupfsPath(systemd)
+		assert.NotNil(t, err, systemd)
+	}
+	for _, cgroupfs := range []string{"/system.slice/runc-abc", "/runc-abc.scope"} {
+		_, err := CgroupfsToSystemdPath(cgroupfs)
+		assert.NotNil(t, err, cgroupfs)
+	}
+
+	assert.False(t, IsSystemdCgroupsPath("/cgrouptest"))
+	assert.False(t, IsSystemdCgroupsPath("testdir/cgrouptest/container"))
+	path, err := ResolveCgroupsPath("/cgrouptest")
+	assert.Nil(t, err)
+	assert.Equal(t, "/cgrouptest", path)
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -15,12 +17,16 @@ import (
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/opencontainers/runtime-tools/generate/seccomp"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 var generateFlags = []cli.Flag{
 	cli.StringSliceFlag{Name: "args", Usage: "command to run in the container"},
+	cli.StringSliceFlag{Name: "args-append", Usage: "append arguments to the command to run in the container"},
+	cli.StringFlag{Name: "command-line", Usage: "full command line to run in a windows container"},
 	cli.StringSliceFlag{Name: "env", Usage: "add environment variable e.g. key=value"},
+	cli.BoolFlag{Name: "env-expand", Usage: "expand $VAR and ${VAR} in environment variable values from the host environment"},
 	cli.StringSliceFlag{Name: "env-file", Usage: "read in a file of environment variables"},
 	cli.StringSliceFlag{Name: "hooks-poststart-add", Usage: "set command to run in poststart hooks"},
 	cli.BoolFlag{Name: "hooks-poststart-remove-all", Usage: "remove all poststart hooks"},
@@ -30,6 +36,7 @@ var generateFlags = []cli.Flag{
 	cli.BoolFlag{Name: "hooks-prestart-remove-all", Usage: "remove all prestart hooks"},
 	cli.StringFlag{Name: "hostname", Usage: "hostname value for the container"},
 	cli.StringSliceFlag{Name: "label", Usage: "add annotations to the configuration e.g. key=value"},
+	cli.StringSliceFlag{Name: "label-file", Usage: "add annotations from a JSON file containing an object of key-value pairs"},
 	cli.StringFlag{Name: "linux-apparmor", Usage: "specifies the the apparmor profile for the container"},
 	cli.IntFlag{Name: "linux-blkio-leaf-weight", Usage: "Block IO (relative leaf weight), the range is from 10 to 1000"},
 	cli.StringSliceFlag{Name: "linux-blkio-leaf-weight-device", Usage: "Block IO (relative device leaf weight), e.g. major:minor:leaf-weight"},
@@ -88,14 +95,19 @@ var generateFlags = []cli.Flag{
 	cli.StringFlag{Name: "linux-selinux-label", Usage: "process selinux label"},
 	cli.StringSliceFlag{Name: "linux-sysctl", Usage: "add sysctl settings e.g net.ipv4.forward=1"},
 	cli.StringSliceFlag{Name: "linux-uidmappings", Usage: "add UIDMappings e.g HostID:ContainerID:Size"},
+	cli.StringFlag{Name: "mount-cgroups", Value: "no", Usage: "mount cgroups (rw,ro,no)"},
+	cli.StringSliceFlag{Name: "mount-spec", Usage: "add a mount e.g. type=bind,source=/src,destination=/dest,\"options=rbind,ro\""},
 	cli.StringSliceFlag{Name: "mounts-add", Usage: "configures additional mounts inside container"},
+	cli.StringSliceFlag{Name: "mounts-file", Usage: "add mounts from a JSON file containing an array of mounts"},
 	cli.StringSliceFlag{Name: "mounts-remove", Usage: "remove destination mountpoints from inside container"},
 	cli.BoolFlag{Name: "mounts-remove-all", Usage: "remove all mounts inside container"},
-	cli.StringFlag{Name: "oci-version", Usage: "specify the version of the Open Container Initiative runtime specification"},
+	cli.BoolFlag{Name: "mounts-sort", Usage: "sort the mounts so that parent directories are mounted first"},
+	cli.BoolFlag{Name: "normalize", Usage: "normalize the configuration into a canonical, diff-friendly form"},
+	cli.StringFlag{Name: "oci-version", Usage: "specify the released version of the Open Container Initiative runtime specification"},
 	cli.StringFlag{Name: "os", Value: runtime.GOOS, Usage: "operating system the container is created for"},
 	cli.StringFlag{Name: "output", Usage: "output file (defaults to stdout)"},
 	cli.BoolFlag{Name: "privileged", Usage: "enable privileged container settings"},
-	cli.StringFlag{Name: "process-cap-add", Usage: "add Linux capabilities to all 5 capability sets"},
+	cli.StringFlag{Name: "process-cap-add", Usage: "add Linux capabilities to all 5 capability sets ('all' adds every capability of the host)"},
 	cli.StringFlag{Name: "process-cap-add-ambient", Usage: "add Linux ambient capabilities"},
 	cli.StringFlag{Name: "process-cap-add-bounding", Usage: "add Linux bounding capabilities"},
 	cli.StringFlag{Name: "process-cap-add-effective", Usage: "add Linux effective capabilities"},
@@ -108,10 +120,11 @@ var generateFlags = []cli.Flag{
 	cli.StringFlag{Name: "process-cap-drop-effective", Usage: "drop Linux effective capabilities"},
 	cli.StringFlag{Name: "process-cap-drop-inheritable", Usage: "drop Linux inheritable capabilities"},
 	cli.StringFlag{Name: "process-cap-drop-permitted", Usage: "drop Linux permitted capabilities"},
+	cli.StringFlag{Name: "process-cap-profile", Usage: "set the bounding, effective and permitted capability sets to a named profile (default, none)"},
 	cli.StringFlag{Name: "process-consolesize", Usage: "specifies the console size in characters (width:height)"},
 	cli.StringFlag{Name: "process-cwd", Value: "/", Usage: "current working directory for the process"},
 	cli.IntFlag{Name: "process-gid", Usage: "gid for the process"},
-	cli.StringSliceFlag{Name: "process-groups", Usage: "supplementary groups for the process"},
+	cli.StringSliceFlag{Name: "process-groups", Usage: "supplementary groups (gid or group name) for the process"},
 	cli.BoolFlag{Name: "process-no-new-privileges", Usage: "set no new privileges bit for the container process"},
 	cli.StringSliceFlag{Name: "process-rlimits-add", Usage: "specifies resource limits for processes inside the container. "},
 	cli.StringSliceFlag{Name: "process-rlimits-remove", Usage: "remove specified resource limits for processes inside the container. "},
@@ -130,6 +143,7 @@ var generateFlags = []cli.Flag{
 	cli.StringFlag{Name: "solaris-max-shm-memory", Usage: "Specifies the maximum amount of shared memory"},
 	cli.StringFlag{Name: "solaris-milestone", Usage: "Specifies the SMF FMRI"},
 	cli.StringFlag{Name: "template", Usage: "base template to use for creating the configuration"},
+	cli.StringSliceFlag{Name: "tmpfs", Usage: "mount a tmpfs e.g. /run:size=65536k,mode=755"},
 	cli.StringSliceFlag{Name: "vm-hypervisor-parameters", Usage: "specifies an array of parameters to pass to the hypervisor"},
 	cli.StringFlag{Name: "vm-hypervisor-path", Usage: "specifies the path to the hypervisor binary that manages the container virtual machine"},
 	cli.StringFlag{Name: "vm-image-format", Usage: "set the format of the container virtual machine root image"},
@@ -178,6 +192,18 @@ var generateCommand = cli.Command{
 			return err
 		}
 
+		if context.Bool("mounts-sort") {
+			specgen.SortMounts()
+		}
+
+		if context.Bool("normalize") {
+			specgen.Normalize()
+		}
+
+		for _, warning := range specgen.Validate() {
+			logrus.Warn(warning)
+		}
+
 		var exportOpts generate.ExportOptions
 		exportOpts.Seccomp = context.Bool("linux-seccomp-only")
 
@@ -203,11 +229,15 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 	}
 
 	if context.IsSet("hostname") {
-		g.SetHostname(context.String("hostname"))
+		if err := g.SetHostname(context.String("hostname")); err != nil {
+			return err
+		}
 	}
 
 	if context.IsSet("oci-version") {
-		g.SetOCIVersion(context.String("oci-version"))
+		if err := g.SetOCIVersion(context.String("oci-version")); err != nil {
+			return err
+		}
 	}
 
 	if context.IsSet("label") {
@@ -221,7 +251,19 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 		}
 	}
 
-	g.SetRootPath(context.String("rootfs-path"))
+	if context.IsSet("label-file") {
+		for _, path := range context.StringSlice("label-file") {
+			if err := g.AddAnnotationsFromFile(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if context.IsSet("rootfs-path") {
+		if err := g.SetRootPath(context.String("rootfs-path")); err != nil {
+			return err
+		}
+	}
 
 	if context.IsSet("rootfs-readonly") {
 		g.SetRootReadonly(context.Bool("rootfs-readonly"))
@@ -265,6 +307,23 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 		g.SetProcessArgs(context.StringSlice("args"))
 	}
 
+	if context.IsSet("command-line") {
+		if context.IsSet("args") || context.IsSet("args-append") {
+			return fmt.Errorf("--command-line conflicts with --args and --args-append")
+		}
+		if err := g.SetProcessCommandLine(context.String("command-line")); err != nil {
+			return err
+		}
+	}
+
+	if context.IsSet("args-append") {
+		g.AddProcessArgs(context.StringSlice("args-append")...)
+	}
+
+	if context.Bool("env-expand") {
+		g.SetEnvExpansion(true)
+	}
+
 	{
 		envs, err := readKVStrings(context.StringSlice("env-file"), context.StringSlice("env"))
 		if err != nil {
@@ -276,18 +335,25 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 			if err != nil {
 				return err
 			}
-			g.AddProcessEnv(name, value)
+			if err := g.AddProcessEnv(name, value); err != nil {
+				return fmt.Errorf("malformed environment variable %q: %v", env, err)
+			}
 		}
 	}
 
 	if context.IsSet("process-groups") {
+		// group names are only resolved against an explicit rootfs
+		rootfs := ""
+		if context.IsSet("rootfs-path") {
+			rootfs = context.String("rootfs-path")
+		}
 		groups := context.StringSlice("process-groups")
 		for _, group := range groups {
-			groupID, err := strconv.Atoi(group)
+			groupID, err := parseGroup(group, rootfs)
 			if err != nil {
 				return err
 			}
-			g.AddProcessAdditionalGid(uint32(groupID))
+			g.AddProcessAdditionalGid(groupID)
 		}
 	}
 
@@ -332,7 +398,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 	}
 
 	if context.IsSet("linux-mount-label") {
-		g.SetLinuxMountLabel(context.String("linux-mount-label"))
+		if err := g.SetLinuxMountLabel(context.String("linux-mount-label")); err != nil {
+			return err
+		}
 	}
 
 	if context.IsSet("linux-sysctl") {
@@ -342,20 +410,48 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 			if len(pair) != 2 {
 				return fmt.Errorf("incorrectly specified sysctl: %s", s)
 			}
-			g.AddLinuxSysctl(pair[0], pair[1])
+			if err := g.AddLinuxNamespacedSysctl(pair[0], pair[1]); err != nil {
+				logrus.Warnf("%v, setting it affects the host", err)
+				g.AddLinuxSysctl(pair[0], pair[1])
+			}
 		}
 	}
 
 	g.SetupPrivileged(context.Bool("privileged"))
 
-	if context.Bool("process-cap-drop-all") {
-		g.ClearProcessCapabilities()
+	if context.IsSet("process-cap-profile") {
+		if err := g.SetCapabilitiesProfile(context.String("process-cap-profile")); err != nil {
+			return err
+		}
+	}
+
+	// "--process-cap-drop all" is the same as --process-cap-drop-all
+	dropCaps := []string{}
+	dropAll := context.Bool("process-cap-drop-all")
+	if context.IsSet("process-cap-drop") {
+		for _, part := range strings.Split(context.String("process-cap-drop"), ",") {
+			if strings.ToLower(part) == "all" {
+				dropAll = true
+			} else {
+				dropCaps = append(dropCaps, part)
+			}
+		}
+	}
+
+	if dropAll {
+		g.DropAllProcessCapabilities()
 	}
 
 	if context.IsSet("process-cap-add") {
 		addCaps := context.String("process-cap-add")
 		parts := strings.Split(addCaps, ",")
 		for _, part := range parts {
+			if strings.ToLower(part) == "all" {
+				if err := g.AddAllProcessCapabilities(); err != nil {
+					return err
+				}
+				continue
+			}
 			if err := g.AddProcessCapability(part); err != nil {
 				return err
 			}
@@ -412,13 +508,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 		}
 	}
 
-	if context.IsSet("process-cap-drop") {
-		addCaps := context.String("process-cap-drop")
-		parts := strings.Split(addCaps, ",")
-		for _, part := range parts {
-			if err := g.DropProcessCapability(part); err != nil {
-				return err
-			}
+	for _, part := range dropCaps {
+		if err := g.DropProcessCapability(part); err != nil {
+			return err
 		}
 	}
 
@@ -514,10 +606,57 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 			if err := json.Unmarshal([]byte(mount), &mnt); err != nil {
 				return err
 			}
+			if mnt.Type == "bind" {
+				if err := g.AddBindMount(mnt.Source, mnt.Destination, mnt.Options); err != nil {
+					return err
+				}
+				continue
+			}
+			g.AddMount(mnt)
+		}
+	}
+
+	if context.IsSet("mount-cgroups") {
+		if err := g.AddCgroupsMount(context.String("mount-cgroups")); err != nil {
+			return err
+		}
+	}
+
+	if context.IsSet("mount-spec") {
+		mounts := context.StringSlice("mount-spec")
+		for _, mount := range mounts {
+			mnt, err := parseMountSpec(mount)
+			if err != nil {
+				return err
+			}
+			if mnt.Type == "bind" {
+				if err := g.AddBindMount(mnt.Source, mnt.Destination, mnt.Options); err != nil {
+					return err
+				}
+				continue
+			}
 			g.AddMount(mnt)
 		}
 	}
 
+	if context.IsSet("mounts-file") {
+		for _, path := range context.StringSlice("mounts-file") {
+			if err := g.AddMountsFromFile(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if context.IsSet("tmpfs") {
+		tmpfsMounts := context.StringSlice("tmpfs")
+		for _, tmpfs := range tmpfsMounts {
+			dest, options := parseTmpfsMount(tmpfs)
+			if err := g.AddTmpfsMount(dest, options); err != nil {
+				return err
+			}
+		}
+	}
+
 	if context.IsSet("hooks-poststart-remove-all") {
 		g.ClearPostStartHooks()
 	}
@@ -576,6 +715,13 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 		}
 	}
 
+	if g.GetRootReadonly() {
+		switch rp := g.GetLinuxRootPropagation(); rp {
+		case "shared", "rshared":
+			logrus.Warnf("rootfs is readonly but has %s propagation, mounts propagated into the container will not be readonly", rp)
+		}
+	}
+
 	for _, uidMap := range uidMaps {
 		hid, cid, size, err := parseIDMapping(uidMap)
 		if err != nil {
@@ -717,7 +863,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 	}
 
 	if context.IsSet("linux-pids-limit") {
-		g.SetLinuxResourcesPidsLimit(context.Int64("linux-pids-limit"))
+		if err := g.SetLinuxResourcesPidsLimit(context.Int64("linux-pids-limit")); err != nil {
+			return err
+		}
 	}
 
 	if context.IsSet("linux-realtime-period") {
@@ -725,7 +873,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 	}
 
 	if context.IsSet("linux-cpus") {
-		g.SetLinuxResourcesCPUCpus(context.String("linux-cpus"))
+		if err := g.SetLinuxResourcesCPUCpus(context.String("linux-cpus")); err != nil {
+			return err
+		}
 	}
 
 	if context.IsSet("linux-hugepage-limits-add") {
@@ -751,7 +901,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 	}
 
 	if context.IsSet("linux-mems") {
-		g.SetLinuxResourcesCPUMems(context.String("linux-mems"))
+		if err := g.SetLinuxResourcesCPUMems(context.String("linux-mems")); err != nil {
+			return err
+		}
 	}
 
 	if context.IsSet("linux-mem-limit") {
@@ -834,7 +986,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 			if err != nil {
 				return err
 			}
-			g.AddProcessRlimits(rType, rHard, rSoft)
+			if err := g.AddProcessRlimits(rType, rHard, rSoft); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -856,7 +1010,9 @@ func setupSpec(g *generate.Generator, context *cli.Context) error {
 			if err != nil {
 				return err
 			}
-			g.AddDevice(dev)
+			if err := g.AddDevice(dev); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -1060,6 +1216,98 @@ func parseIDMapping(idms string) (uint32, uint32, uint32, error) {
 	return uint32(hid), uint32(cid), uint32(size), nil
 }
 
+// parseGroup returns the gid for a numeric group id or, failing that, looks
+// the group name up in the /etc/group file of the given rootfs.  Group names
+// cannot be resolved without a rootfs.
+func parseGroup(group string, rootfs string) (uint32, error) {
+	if gid, err := strconv.ParseUint(group, 10, 32); err == nil {
+		return uint32(gid), nil
+	}
+	if rootfs == "" {
+		return 0, fmt.Errorf("cannot resolve group name %q without --rootfs-path, use a numeric gid", group)
+	}
+
+	fh, err := os.Open(filepath.Join(rootfs, "etc", "group"))
+	if err != nil {
+		return 0, fmt.Errorf("cannot resolve group %q: %v", group, err)
+	}
+	defer fh.Close()
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// name:password:gid:members
+		parts := strings.Split(line, ":")
+		if len(parts) < 3 || parts[0] != group {
+			continue
+		}
+		gid, err := strconv.ParseUint(parts[2], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid gid %q for group %q", parts[2], group)
+		}
+		return uint32(gid), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("group %q not found in %s", group, filepath.Join(rootfs, "etc", "group"))
+}
+
+// parseMountSpec parses a mount given as comma-separated key=value fields,
+// e.g. type=bind,source=/src,destination=/dest,"options=rbind,ro".  Fields
+// are CSV-encoded, so values containing commas (like a list of options)
+// can be quoted, and colons in paths need no escaping.
+func parseMountSpec(spec string) (rspec.Mount, error) {
+	mnt := rspec.Mount{}
+	fields, err := csv.NewReader(strings.NewReader(spec)).Read()
+	if err != nil {
+		return mnt, fmt.Errorf("invalid mount spec %q: %v", spec, err)
+	}
+
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return mnt, fmt.Errorf("invalid field %q in mount spec %q, must be key=value", field, spec)
+		}
+		key, value := strings.TrimSpace(kv[0]), kv[1]
+		switch key {
+		case "type":
+			mnt.Type = value
+		case "source", "src":
+			mnt.Source = value
+		case "destination", "dst", "target":
+			mnt.Destination = value
+		case "options", "option":
+			for _, option := range strings.Split(value, ",") {
+				if option != "" {
+					mnt.Options = append(mnt.Options, option)
+				}
+			}
+		default:
+			return mnt, fmt.Errorf("unknown field %q in mount spec %q", key, spec)
+		}
+	}
+
+	if mnt.Destination == "" {
+		return mnt, fmt.Errorf("mount spec %q has no destination", spec)
+	}
+	return mnt, nil
+}
+
+// parseTmpfsMount parses DEST[:OPTIONS], where OPTIONS is a comma-separated
+// list of tmpfs options.
+func parseTmpfsMount(tmpfs string) (string, []string) {
+	parts := strings.SplitN(tmpfs, ":", 2)
+	if len(parts) == 1 || parts[1] == "" {
+		return parts[0], nil
+	}
+	return parts[0], strings.Split(parts[1], ",")
+}
+
 func parseHugepageLimit(pageLimit string) (string, uint64, error) {
 	pl := strings.Split(pageLimit, ":")
 	if len(pl) != 2 {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGroup(t *testing.T) {
+	rootfs, err := ioutil.TempDir("", "oci-runtime-tool-rootfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootfs)
+	if err := os.Mkdir(filepath.Join(rootfs, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	group := "# comment\nroot:x:0:\nwheel:x:10:root\nbroken:x:gid:\n"
+	if err := ioutil.WriteFile(filepath.Join(rootfs, "etc", "group"), []byte(group), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		group    string
+		rootfs   string
+		expected uint32
+		err      bool
+	}{
+		{group: "100", expected: 100},
+		{group: "100", rootfs: rootfs, expected: 100},
+		{group: "wheel", rootfs: rootfs, expected: 10},
+		{group: "wheel", err: true},
+		{group: "missing", rootfs: rootfs, err: true},
+		{group: "broken", rootfs: rootfs, err: true},
+	} {
+		gid, err := parseGroup(tc.group, tc.rootfs)
+		if tc.err {
+			assert.Error(t, err, tc.group)
+			continue
+		}
+		assert.NoError(t, err, tc.group)
+		assert.Equal(t, tc.expected, gid, tc.group)
+	}
+}
+
+func TestParseMountSpec(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		expected rspec.Mount
+	}{
+		{
+			spec:     `type=bind,source=/volumes/a:b,destination=/data`,
+			expected: rspec.Mount{Type: "bind", Source: "/volumes/a:b", Destination: "/data"},
+		},
+		{
+			spec:     `src=C:\folder-on-host,dst=C:\folder-inside-container,options=ro`,
+			expected: rspec.Mount{Source: `C:\folder-on-host`, Destination: `C:\folder-inside-container`, Options: []string{"ro"}},
+		},
+		{
+			spec:     `type=bind,source=/src,target=/dest,"options=rbind,ro"`,
+			expected: rspec.Mount{Type: "bind", Source: "/src", Destination: "/dest", Options: []string{"rbind", "ro"}},
+		},
+		{
+			spec:     `type=bind,"source=/volumes/a,b",destination=/data`,
+			expected: rspec.Mount{Type: "bind", Source: "/volumes/a,b", Destination: "/data"},
+		},
+		{
+			spec:     `type=bind,"source=/volumes/""quoted""",destination=/data`,
+			expected: rspec.Mount{Type: "bind", Source: `/volumes/"quoted"`, Destination: "/data"},
+		},
+		{
+			spec:     `type=tmpfs,source=tmpfs,destination=/tmp,options=`,
+			expected: rspec.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/tmp"},
+		},
+	} {
+		mnt, err := parseMountSpec(tc.spec)
+		assert.NoError(t, err, tc.spec)
+		assert.Equal(t, tc.expected, mnt, tc.spec)
+	}
+
+	for _, spec := range []string{
+		`type=bind,source=/src`,
+		`type=bind,source=/src,destination=`,
+		`type=bind,source=/src,destination=/dest,mode=ro`,
+		`bind,source=/src,destination=/dest`,
+		`type=bind,"options=rbind,destination=/dest`,
+		``,
+	} {
+		_, err := parseMountSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -45,6 +45,7 @@ func main() {
 	app.Commands = []cli.Command{
 		generateCommand,
 		bundleValidateCommand,
+		snapshotCommand,
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -56,7 +57,7 @@ func before(context *cli.Context) error {
 	logLevelString := context.GlobalString("log-level")
 	logLevel, err := logrus.ParseLevel(logLevelString)
 	if err != nil {
-		logrus.Fatalf(err.Error())
+		logrus.Fatal(err)
 	}
 	logrus.SetLevel(logLevel)
 
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/validate"
+	"github.com/sirupsen/logrus"
+	"github.com/syndtr/gocapability/capability"
+	"github.com/urfave/cli"
+)
+
+// snapshotUncertainAnnotation lists the configuration fields of a snapshot
+// which may not reproduce the process faithfully.
+const snapshotUncertainAnnotation = "org.opencontainers.runtime-tools.snapshot.uncertain"
+
+var snapshotFlags = []cli.Flag{
+	cli.StringFlag{Name: "output", Usage: "output file (defaults to stdout)"},
+	cli.IntFlag{Name: "pid", Usage: "process ID of the running process"},
+}
+
+var snapshotCommand = cli.Command{
+	Name:   "snapshot",
+	Usage:  "generate a best-effort OCI spec file from a running process",
+	Flags:  snapshotFlags,
+	Before: before,
+	Action: func(context *cli.Context) error {
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("snapshot is only supported on linux")
+		}
+		if !context.IsSet("pid") {
+			return fmt.Errorf("--pid is required")
+		}
+
+		specgen, err := snapshot(context.Int("pid"))
+		if err != nil {
+			return err
+		}
+
+		if context.IsSet("output") {
+			return specgen.SaveToFile(context.String("output"), generate.ExportOptions{})
+		}
+		return specgen.Save(os.Stdout, generate.ExportOptions{})
+	},
+}
+
+// procNamespaces maps the namespace names of the generator to their names
+// in /proc/<pid>/ns.
+var procNamespaces = map[string]string{
+	"cgroup":  "cgroup",
+	"ipc":     "ipc",
+	"mount":   "mnt",
+	"network": "net",
+	"pid":     "pid",
+	"user":    "user",
+	"uts":     "uts",
+}
+
+// procRlimits maps the limit names of /proc/<pid>/limits to rlimit types.
+var procRlimits = map[string]string{
+	"Max cpu time":          "RLIMIT_CPU",
+	"Max file size":         "RLIMIT_FSIZE",
+	"Max data size":         "RLIMIT_DATA",
+	"Max stack size":        "RLIMIT_STACK",
+	"Max core file size":    "RLIMIT_CORE",
+	"Max resident set":      "RLIMIT_RSS",
+	"Max processes":         "RLIMIT_NPROC",
+	"Max open files":        "RLIMIT_NOFILE",
+	"Max locked memory":     "RLIMIT_MEMLOCK",
+	"Max address space":     "RLIMIT_AS",
+	"Max file locks":        "RLIMIT_LOCKS",
+	"Max pending signals":   "RLIMIT_SIGPENDING",
+	"Max msgqueue size":     "RLIMIT_MSGQUEUE",
+	"Max nice priority":     "RLIMIT_NICE",
+	"Max realtime priority": "RLIMIT_RTPRIO",
+	"Max realtime timeout":  "RLIMIT_RTTIME",
+}
+
+// snapshot builds a configuration reproducing the process pid as far as
+// /proc/<pid> tells.  Fields which cannot be read back, like the seccomp
+// filter, the devices or the resource limits of the cgroup, are left
+// unset.  Fields which are read back but may differ from the original
+// configuration are listed in snapshotUncertainAnnotation.
+func snapshot(pid int) (*generate.Generator, error) {
+	proc := filepath.Join("/proc", strconv.Itoa(pid))
+	g := &generate.Generator{
+		Config: &rspec.Spec{Version: rspec.Version},
+	}
+	var uncertain []string
+
+	if err := snapshotProcess(g, proc); err != nil {
+		return nil, err
+	}
+
+	root, err := os.Readlink(filepath.Join(proc, "root"))
+	if err != nil {
+		return nil, err
+	}
+	if err := g.SetRootPath(root); err != nil {
+		return nil, err
+	}
+
+	namespaces, err := snapshotNamespaces(g, proc)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaces) > 0 {
+		// the namespaces may have been created or joined
+		uncertain = append(uncertain, "linux.namespaces")
+	}
+	if namespaces["uts"] {
+		// the hostname lives in the UTS namespace of the process
+		uncertain = append(uncertain, "hostname")
+	}
+
+	if namespaces["user"] {
+		if err := snapshotIDMappings(g, proc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := snapshotCgroupsPath(g, proc); err != nil {
+		return nil, err
+	}
+
+	if namespaces["mount"] {
+		if err := snapshotMounts(g, proc); err != nil {
+			return nil, err
+		}
+		// bind mount sources and propagation are not recorded
+		uncertain = append(uncertain, "mounts")
+	}
+
+	if len(uncertain) > 0 {
+		g.AddAnnotation(snapshotUncertainAnnotation, strings.Join(uncertain, ","))
+	}
+	return g, nil
+}
+
+func snapshotProcess(g *generate.Generator, proc string) error {
+	cmdline, err := ioutil.ReadFile(filepath.Join(proc, "cmdline"))
+	if err != nil {
+		return err
+	}
+	cmdline = bytes.TrimRight(cmdline, "\x00")
+	if len(cmdline) == 0 {
+		return fmt.Errorf("%s has no command line, it may be a kernel thread or a zombie", proc)
+	}
+	var args []string
+	for _, arg := range bytes.Split(cmdline, []byte("\x00")) {
+		args = append(args, string(arg))
+	}
+	g.SetProcessArgs(args)
+
+	environ, err := ioutil.ReadFile(filepath.Join(proc, "environ"))
+	if err != nil {
+		return err
+	}
+	if environ = bytes.TrimRight(environ, "\x00"); len(environ) > 0 {
+		for _, env := range bytes.Split(environ, []byte("\x00")) {
+			if err := g.AddMultipleProcessEnv([]string{string(env)}); err != nil {
+				logrus.Warnf("skipping environment variable: %v", err)
+			}
+		}
+	}
+
+	cwd, err := os.Readlink(filepath.Join(proc, "cwd"))
+	if err != nil {
+		return err
+	}
+	g.SetProcessCwd(cwd)
+
+	status, err := readProcStatus(proc)
+	if err != nil {
+		return err
+	}
+	if ids := status["Uid"]; len(ids) > 0 {
+		uid, err := strconv.ParseUint(ids[0], 10, 32)
+		if err != nil {
+			return err
+		}
+		g.SetProcessUID(uint32(uid))
+	}
+	if ids := status["Gid"]; len(ids) > 0 {
+		gid, err := strconv.ParseUint(ids[0], 10, 32)
+		if err != nil {
+			return err
+		}
+		g.SetProcessGID(uint32(gid))
+	}
+	for _, group := range status["Groups"] {
+		gid, err := strconv.ParseUint(group, 10, 32)
+		if err != nil {
+			return err
+		}
+		g.AddProcessAdditionalGid(uint32(gid))
+	}
+	if noNewPrivs := status["NoNewPrivs"]; len(noNewPrivs) > 0 {
+		g.SetProcessNoNewPrivileges(noNewPrivs[0] == "1")
+	}
+
+	caps := &rspec.LinuxCapabilities{}
+	for _, set := range []struct {
+		field string
+		caps  *[]string
+	}{
+		{"CapBnd", &caps.Bounding},
+		{"CapEff", &caps.Effective},
+		{"CapInh", &caps.Inheritable},
+		{"CapPrm", &caps.Permitted},
+		{"CapAmb", &caps.Ambient},
+	} {
+		if values := status[set.field]; len(values) > 0 {
+			if *set.caps, err = parseCapabilities(values[0]); err != nil {
+				return err
+			}
+		}
+	}
+	g.Config.Process.Capabilities = caps
+
+	oomScoreAdj, err := ioutil.ReadFile(filepath.Join(proc, "oom_score_adj"))
+	if err != nil {
+		return err
+	}
+	adj, err := strconv.Atoi(strings.TrimSpace(string(oomScoreAdj)))
+	if err != nil {
+		return err
+	}
+	g.SetProcessOOMScoreAdj(adj)
+
+	return snapshotRlimits(g, proc)
+}
+
+// readProcStatus returns the fields of /proc/<pid>/status.
+func readProcStatus(proc string) (map[string][]string, error) {
+	f, err := os.Open(filepath.Join(proc, "status"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	status := map[string][]string{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		kv := strings.SplitN(s.Text(), ":", 2)
+		if len(kv) == 2 {
+			status[kv[0]] = strings.Fields(kv[1])
+		}
+	}
+	return status, s.Err()
+}
+
+// parseCapabilities returns the names of the capabilities in a hexadecimal
+// capability mask of /proc/<pid>/status.
+func parseCapabilities(mask string) ([]string, error) {
+	bits, err := strconv.ParseUint(mask, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capability mask %q: %v", mask, err)
+	}
+
+	caps := []string{}
+	last := validate.LastCap()
+	for _, cap := range capability.List() {
+		if cap > last {
+			continue
+		}
+		if bits&(1<<uint(cap)) != 0 {
+			caps = append(caps, validate.CapabilityString(cap))
+		}
+	}
+	sort.Strings(caps)
+	return caps, nil
+}
+
+func snapshotRlimits(g *generate.Generator, proc string) error {
+	f, err := os.Open(filepath.Join(proc, "limits"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Limit                     Soft Limit           Hard Limit           Units
+		line := s.Text()
+		if len(line) < 26 {
+			continue
+		}
+		rType, ok := procRlimits[strings.TrimSpace(line[:26])]
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(line[26:])
+		if len(fields) < 2 {
+			continue
+		}
+		soft, err := parseRlimitValue(fields[0])
+		if err != nil {
+			return err
+		}
+		hard, err := parseRlimitValue(fields[1])
+		if err != nil {
+			return err
+		}
+		if err := g.AddProcessRlimits(rType, hard, soft); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+func parseRlimitValue(value string) (uint64, error) {
+	if value == "unlimited" {
+		return ^uint64(0), nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// snapshotNamespaces adds the namespaces in which the process differs from
+// the current process, and returns them.
+func snapshotNamespaces(g *generate.Generator, proc string) (map[string]bool, error) {
+	namespaces := map[string]bool{}
+	for _, ns := range generate.Namespaces {
+		procNs := procNamespaces[ns]
+		self, err := os.Readlink(filepath.Join("/proc/self/ns", procNs))
+		if os.IsNotExist(err) {
+			// the kernel does not support this namespace
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		other, err := os.Readlink(filepath.Join(proc, "ns", procNs))
+		if err != nil {
+			return nil, err
+		}
+		if self != other {
+			if err := g.AddOrReplaceLinuxNamespace(ns, ""); err != nil {
+				return nil, err
+			}
+			namespaces[ns] = true
+		}
+	}
+	return namespaces, nil
+}
+
+func snapshotIDMappings(g *generate.Generator, proc string) error {
+	for _, mapping := range []struct {
+		file string
+		add  func(hid, cid, size uint32)
+	}{
+		{"uid_map", g.AddLinuxUIDMapping},
+		{"gid_map", g.AddLinuxGIDMapping},
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(proc, mapping.file))
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var cid, hid, size uint32
+			if _, err := fmt.Sscanf(line, "%d %d %d", &cid, &hid, &size); err != nil {
+				return fmt.Errorf("invalid %s line %q: %v", mapping.file, line, err)
+			}
+			mapping.add(hid, cid, size)
+		}
+	}
+	return nil
+}
+
+// snapshotCgroupsPath sets the cgroups path from the cgroup v2 hierarchy,
+// or from the first cgroup v1 hierarchy otherwise.
+func snapshotCgroupsPath(g *generate.Generator, proc string) error {
+	data, err := ioutil.ReadFile(filepath.Join(proc, "cgroup"))
+	if err != nil {
+		return err
+	}
+
+	var path string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			path = parts[2]
+			break
+		}
+		if path == "" && parts[1] != "" && !strings.HasPrefix(parts[1], "name=") {
+			path = parts[2]
+		}
+	}
+	if path != "" && path != "/" {
+		g.SetLinuxCgroupsPath(path)
+	}
+	return nil
+}
+
+// snapshotMounts adds the mounts of the process, as seen from its root.
+func snapshotMounts(g *generate.Generator, proc string) error {
+	f, err := os.Open(filepath.Join(proc, "mountinfo"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		parts := strings.SplitN(s.Text(), " - ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields := strings.Fields(parts[0])
+		fsFields := strings.Fields(parts[1])
+		if len(fields) < 6 || len(fsFields) < 2 {
+			continue
+		}
+		root, destination, options := fields[3], fields[4], strings.Split(fields[5], ",")
+		if destination == "/" {
+			continue
+		}
+
+		mnt := rspec.Mount{
+			Destination: destination,
+			Type:        fsFields[0],
+			Source:      fsFields[1],
+			Options:     options,
+		}
+		if root != "/" {
+			// a bind mount of a directory within the source filesystem
+			mnt.Type = "bind"
+			mnt.Source = root
+			mnt.Options = append([]string{"rbind"}, options...)
+		}
+		g.AddMount(mnt)
+	}
+	return s.Err()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("capabilities are only known on Linux")
+	}
+
+	for _, tc := range []struct {
+		mask     string
+		expected []string
+		err      bool
+	}{
+		{mask: "0000000000000000", expected: []string{}},
+		{mask: "0000000000000003", expected: []string{"CAP_CHOWN", "CAP_DAC_OVERRIDE"}},
+		{mask: "0000000000200020", expected: []string{"CAP_KILL", "CAP_SYS_ADMIN"}},
+		{mask: "not-hex", err: true},
+		{mask: "", err: true},
+	} {
+		caps, err := parseCapabilities(tc.mask)
+		if tc.err {
+			assert.Error(t, err, tc.mask)
+			continue
+		}
+		assert.NoError(t, err, tc.mask)
+		assert.Equal(t, tc.expected, caps, tc.mask)
+	}
+}
+
+func TestParseRlimitValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected uint64
+		err      bool
+	}{
+		{value: "0", expected: 0},
+		{value: "1024", expected: 1024},
+		{value: "unlimited", expected: ^uint64(0)},
+		{value: "-1", err: true},
+		{value: "bytes", err: true},
+	} {
+		value, err := parseRlimitValue(tc.value)
+		if tc.err {
+			assert.Error(t, err, tc.value)
+			continue
+		}
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, value, tc.value)
+	}
+}
+
+// writeProc writes a fake /proc/<pid> file and returns its directory.
+func writeProc(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "oci-runtime-tool-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadProcStatus(t *testing.T) {
+	proc := writeProc(t, "status", `Name:	sleep
+Umask:	0022
+Uid:	1000	1000	1000	1000
+Groups:	4 24 27
+CapEff:	0000000000000000
+NoColon
+`)
+	defer os.RemoveAll(proc)
+
+	status, err := readProcStatus(proc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sleep"}, status["Name"])
+	assert.Equal(t, []string{"1000", "1000", "1000", "1000"}, status["Uid"])
+	assert.Equal(t, []string{"4", "24", "27"}, status["Groups"])
+	assert.Equal(t, []string{"0000000000000000"}, status["CapEff"])
+	assert.NotContains(t, status, "NoColon")
+}
+
+func TestSnapshotRlimits(t *testing.T) {
+	proc := writeProc(t, "limits", `Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            1024                 4096                 files
+Max realtime timeout      unlimited            unlimited            us
+Unknown limit             1                    2                    things
+`)
+	defer os.RemoveAll(proc)
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearProcessRlimits()
+	assert.NoError(t, snapshotRlimits(&g, proc))
+	assert.Equal(t, []rspec.POSIXRlimit{
+		{Type: "RLIMIT_CPU", Hard: ^uint64(0), Soft: ^uint64(0)},
+		{Type: "RLIMIT_NOFILE", Hard: 4096, Soft: 1024},
+		{Type: "RLIMIT_RTTIME", Hard: ^uint64(0), Soft: ^uint64(0)},
+	}, g.Config.Process.Rlimits)
+}
+
+func TestSnapshotIDMappings(t *testing.T) {
+	proc := writeProc(t, "uid_map", "         0     100000      65536\n")
+	defer os.RemoveAll(proc)
+	if err := ioutil.WriteFile(filepath.Join(proc, "gid_map"), []byte("0 100000 1\n1 200000 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, snapshotIDMappings(&g, proc))
+	assert.Equal(t, []rspec.LinuxIDMapping{
+		{ContainerID: 0, HostID: 100000, Size: 65536},
+	}, g.Config.Linux.UIDMappings)
+	assert.Equal(t, []rspec.LinuxIDMapping{
+		{ContainerID: 0, HostID: 100000, Size: 1},
+		{ContainerID: 1, HostID: 200000, Size: 10},
+	}, g.Config.Linux.GIDMappings)
+
+	if err := ioutil.WriteFile(filepath.Join(proc, "uid_map"), []byte("0 100000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, snapshotIDMappings(&g, proc))
+}
+
+func TestSnapshotCgroupsPath(t *testing.T) {
+	for _, tc := range []struct {
+		cgroup   string
+		expected string
+	}{
+		{cgroup: "0::/user.slice/app.scope\n", expected: "/user.slice/app.scope"},
+		{cgroup: "12:name=systemd:/named\n11:memory:/v1/memory\n10:cpu,cpuacct:/v1/cpu\n", expected: "/v1/memory"},
+		{cgroup: "1:name=systemd:/named\n0::/unified\n", expected: "/unified"},
+		{cgroup: "0::/\n", expected: ""},
+	} {
+		proc := writeProc(t, "cgroup", tc.cgroup)
+		g, err := generate.New("linux")
+		if err != nil {
+			os.RemoveAll(proc)
+			t.Fatal(err)
+		}
+		assert.NoError(t, snapshotCgroupsPath(&g, proc), tc.cgroup)
+		assert.Equal(t, tc.expected, g.Config.Linux.CgroupsPath, tc.cgroup)
+		os.RemoveAll(proc)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/hashicorp/go-multierror"
 	rfc2119 "github.com/opencontainers/runtime-tools/error"
 	"github.com/opencontainers/runtime-tools/specerror"
 	"github.com/opencontainers/runtime-tools/validate"
@@ -12,6 +13,7 @@ import (
 )
 
 var bundleValidateFlags = []cli.Flag{
+	cli.StringFlag{Name: "features", Usage: "path to the features JSON of the runtime the bundle is meant for"},
 	cli.StringFlag{Name: "path", Value: ".", Usage: "path to a bundle"},
 	cli.StringFlag{Name: "platform", Value: runtime.GOOS, Usage: "platform of the target bundle (linux, windows, solaris)"},
 }
@@ -36,6 +38,22 @@ var bundleValidateCommand = cli.Command{
 			return err
 		}
 
+		if context.IsSet("features") {
+			features, err := validate.LoadFeatures(context.String("features"))
+			if err != nil {
+				return err
+			}
+			if err := v.CheckFeatures(features); err != nil {
+				if merr, ok := err.(*multierror.Error); ok {
+					for _, e := range merr.Errors {
+						logrus.Warn(e)
+					}
+				} else {
+					logrus.Warn(err)
+				}
+			}
+		}
+
 		if err := v.CheckAll(); err != nil {
 			levelErrors, err := specerror.SplitLevel(err, complianceLevel)
 			if err != nil {
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"unsafe"
 
 	"github.com/mndrix/tap-go"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
@@ -24,6 +25,7 @@ import (
 	"github.com/opencontainers/runtime-tools/cmd/runtimetest/mount"
 	rfc2119 "github.com/opencontainers/runtime-tools/error"
 	"github.com/opencontainers/runtime-tools/specerror"
+	"github.com/opencontainers/runtime-tools/validate"
 	"github.com/opencontainers/selinux/go-selinux/label"
 
 	"golang.org/x/sys/unix"
@@ -58,6 +60,8 @@ var (
 	// NOTE: check if /dev/ptmx is a symlink to /dev/pts/ptmx.
 	// os.Readlink() returns only a relative path "pts/ptmx" instead of
 	// an absolute path /dev/pts/ptmx.
+	// /dev/ptmx may also be a character device 5:2, e.g. when the runtime
+	// bind mounts /dev/pts/ptmx on it, which is accepted as well.
 	defaultSymlinks = map[string]string{
 		"/dev/fd":     "/proc/self/fd",
 		"/dev/ptmx":   "pts/ptmx",
@@ -115,6 +119,9 @@ var (
 type complianceTester struct {
 	harness         *tap.T
 	complianceLevel rfc2119.Level
+	// exec is set when runtimetest was exec'ed into a running container
+	// instead of being its init process.
+	exec bool
 }
 
 func (c *complianceTester) Ok(test bool, condition specerror.Code, version string, description string) (rfcError *rfc2119.Error, err error) {
@@ -257,6 +264,206 @@ func (c *complianceTester) validateLinuxProcess(spec *rspec.Spec) error {
 	noNewPrivileges := ret == 1
 	c.harness.Ok(spec.Process.NoNewPrivileges == noNewPrivileges, "has expected noNewPrivileges")
 
+	return c.validatePrimaryGroup(spec)
+}
+
+// validatePrimaryGroup checks that process.user.gid is the real and
+// effective group ID, and not only one of the supplementary groups.
+func (c *complianceTester) validatePrimaryGroup(spec *rspec.Spec) error {
+	status, err := ioutil.ReadFile("/proc/self/status")
+	if err != nil {
+		return err
+	}
+
+	var gids, groups []string
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "Gid:":
+			gids = fields[1:]
+		case "Groups:":
+			groups = fields[1:]
+		}
+	}
+	if len(gids) < 2 {
+		return fmt.Errorf("cannot find the group IDs in /proc/self/status")
+	}
+
+	expected := strconv.FormatUint(uint64(spec.Process.User.GID), 10)
+	supplementary := false
+	for _, group := range groups {
+		if group == expected {
+			supplementary = true
+		}
+	}
+
+	primary := gids[0] == expected && gids[1] == expected
+	c.harness.Ok(primary, "process.user.gid is the real and effective group ID")
+	diagnostic := map[string]interface{}{
+		"expected":  expected,
+		"real":      gids[0],
+		"effective": gids[1],
+	}
+	if !primary && supplementary {
+		diagnostic["error"] = "process.user.gid is only a supplementary group"
+	}
+	c.harness.YAML(diagnostic)
+	return nil
+}
+
+// strictEnvAnnotation enables checking that the process environment holds
+// exactly process.env, in the same order and with the same duplicates.
+// Most runtimes normalize the environment, so it is off unless the value
+// is "true".
+const strictEnvAnnotation = "org.opencontainers.runtimetest.env.strict"
+
+// envMismatches returns the positions where actual differs from expected,
+// with "" standing for a missing entry.
+func envMismatches(expected, actual []string) []map[string]interface{} {
+	var mismatches []map[string]interface{}
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		var e, a string
+		if i < len(expected) {
+			e = expected[i]
+		}
+		if i < len(actual) {
+			a = actual[i]
+		}
+		if e != a {
+			mismatches = append(mismatches, map[string]interface{}{
+				"index":    i,
+				"expected": e,
+				"actual":   a,
+			})
+		}
+	}
+	return mismatches
+}
+
+// absentEnvAnnotation holds a comma-separated list of variable names which
+// must not be in the process environment, e.g. variables only set in the
+// environment of the runtime.
+const absentEnvAnnotation = "org.opencontainers.runtimetest.env.absent"
+
+// readEnviron returns the environment of the process as the kernel passed
+// it, before any changes by the Go runtime.
+func readEnviron() ([]string, error) {
+	environ, err := ioutil.ReadFile("/proc/self/environ")
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	if environ = bytes.Trim(environ, "\x00"); len(environ) > 0 {
+		for _, e := range bytes.Split(environ, []byte("\x00")) {
+			env = append(env, string(e))
+		}
+	}
+	return env, nil
+}
+
+// envPresent returns the entries of env which set one of names.
+func envPresent(names []string, env []string) []string {
+	var present []string
+	for _, e := range env {
+		name := strings.SplitN(e, "=", 2)[0]
+		for _, n := range names {
+			if name == n {
+				present = append(present, e)
+				break
+			}
+		}
+	}
+	return present
+}
+
+func (c *complianceTester) validateAbsentEnv(spec *rspec.Spec) error {
+	value := spec.Annotations[absentEnvAnnotation]
+	if value == "" {
+		c.harness.Skip(1, fmt.Sprintf("%s not set", absentEnvAnnotation))
+		return nil
+	}
+
+	actual, err := readEnviron()
+	if err != nil {
+		return err
+	}
+
+	present := envPresent(strings.Split(value, ","), actual)
+	c.harness.Ok(len(present) == 0, "does not inherit variables outside of process.env")
+	if len(present) > 0 {
+		c.harness.YAML(map[string]interface{}{
+			"present": present,
+		})
+	}
+	return nil
+}
+
+func (c *complianceTester) validateStrictEnv(spec *rspec.Spec) error {
+	if spec.Annotations[strictEnvAnnotation] != "true" {
+		c.harness.Skip(1, fmt.Sprintf("%s not set", strictEnvAnnotation))
+		return nil
+	}
+	if spec.Process == nil {
+		c.harness.Skip(1, "process not set")
+		return nil
+	}
+
+	actual, err := readEnviron()
+	if err != nil {
+		return err
+	}
+
+	mismatches := envMismatches(spec.Process.Env, actual)
+	c.harness.Ok(len(mismatches) == 0, "has exactly the expected environment in order")
+	if len(mismatches) > 0 {
+		c.harness.YAML(map[string]interface{}{
+			"mismatches": mismatches,
+		})
+	}
+	return nil
+}
+
+// isTerminal returns true if fd refers to a terminal.
+func isTerminal(fd uintptr) bool {
+	var termios unix.Termios
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, unix.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+func (c *complianceTester) validateTerminal(spec *rspec.Spec) error {
+	if spec.Process == nil {
+		c.harness.Skip(1, "process not set")
+		return nil
+	}
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(int(os.Stdin.Fd()), &stat); err != nil {
+		c.harness.Skip(1, fmt.Sprintf("stdin is not available: %v", err))
+	} else {
+		stdinTerminal := isTerminal(os.Stdin.Fd())
+		if spec.Process.Terminal {
+			c.harness.Ok(stdinTerminal, "stdin is a terminal when process.terminal is true")
+		} else {
+			c.harness.Ok(!stdinTerminal, "stdin is not a terminal when process.terminal is false")
+		}
+		c.harness.YAML(map[string]bool{
+			"expected": spec.Process.Terminal,
+			"actual":   stdinTerminal,
+		})
+	}
+
+	if spec.Process.Terminal {
+		// /dev/console is checked with the default devices
+		return nil
+	}
+	_, err := os.Lstat("/dev/console")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	c.harness.Ok(os.IsNotExist(err), "/dev/console does not exist when process.terminal is false")
 	return nil
 }
 
@@ -312,7 +519,7 @@ func (c *complianceTester) validateCapabilities(spec *rspec.Spec) error {
 				continue
 			}
 
-			capKey := fmt.Sprintf("CAP_%s", strings.ToUpper(cap.String()))
+			capKey := validate.CapabilityString(cap)
 			expectedSet := expectedCaps[capKey]
 			actuallySet := processCaps.Get(capType.capType, cap)
 			if expectedSet {
@@ -344,6 +551,35 @@ func (c *complianceTester) validateHostname(spec *rspec.Spec) error {
 	return nil
 }
 
+func (c *complianceTester) validatePIDNamespace(spec *rspec.Spec) error {
+	newPIDNamespace := false
+	if spec.Linux != nil {
+		for _, ns := range spec.Linux.Namespaces {
+			if ns.Type == rspec.PIDNamespace && ns.Path == "" {
+				newPIDNamespace = true
+			}
+		}
+	}
+	if !newPIDNamespace {
+		c.harness.Skip(1, "no new pid namespace, the process is not pid 1")
+		return nil
+	}
+	if c.exec {
+		c.harness.Skip(1, "exec'ed process, the process is not pid 1")
+		return nil
+	}
+
+	pid := os.Getpid()
+	c.harness.Ok(pid == 1, "process is pid 1 in the new pid namespace")
+	if pid != 1 {
+		c.harness.YAML(map[string]int{
+			"expected": 1,
+			"actual":   pid,
+		})
+	}
+	return nil
+}
+
 func (c *complianceTester) validateRlimits(spec *rspec.Spec) error {
 	if spec.Process == nil {
 		c.harness.Skip(1, "process.rlimits not set")
@@ -351,7 +587,7 @@ func (c *complianceTester) validateRlimits(spec *rspec.Spec) error {
 	}
 
 	for _, r := range spec.Process.Rlimits {
-		rl, err := strToRlimit(r.Type)
+		rl, err := validate.RlimitFromString(r.Type)
 		if err != nil {
 			return err
 		}
@@ -394,7 +630,17 @@ func (c *complianceTester) validateSysctls(spec *rspec.Spec) error {
 		return nil
 	}
 
+	namespaces := map[rspec.LinuxNamespaceType]bool{}
+	for _, ns := range spec.Linux.Namespaces {
+		namespaces[ns.Type] = true
+	}
+
 	for k, v := range spec.Linux.Sysctl {
+		ns, namespaced := validate.SysctlNamespace(k)
+		if namespaced && !namespaces[ns] {
+			c.harness.Skip(1, fmt.Sprintf("sysctl %v requires a %s namespace", k, ns))
+			continue
+		}
 		keyPath := filepath.Join("/proc/sys", strings.Replace(k, ".", "/", -1))
 		vBytes, err := ioutil.ReadFile(keyPath)
 		if err != nil {
@@ -402,11 +648,15 @@ func (c *complianceTester) validateSysctls(spec *rspec.Spec) error {
 		}
 		value := strings.TrimSpace(string(bytes.Trim(vBytes, "\x00")))
 		c.harness.Ok(value == v, fmt.Sprintf("has expected sysctl %v", k))
-		c.harness.YAML(map[string]string{
+		diagnostic := map[string]string{
 			"sysctl":   k,
 			"expected": v,
 			"actual":   value,
-		})
+		}
+		if !namespaced {
+			diagnostic["warning"] = "sysctl is not namespaced, setting it affects the host"
+		}
+		c.harness.YAML(diagnostic)
 	}
 	return nil
 }
@@ -495,7 +745,7 @@ func testDirectoryWriteAccess(path string) (writable bool, err error) {
 		return false, nil
 	}
 	tmpfile.Close()
-	return true, os.RemoveAll(filepath.Join(path, tmpfile.Name()))
+	return true, os.RemoveAll(tmpfile.Name())
 }
 
 func testFileWriteAccess(path string) (readable bool, err error) {
@@ -526,6 +776,7 @@ func (c *complianceTester) validateRootFS(spec *rspec.Spec) error {
 			"level":     rfcError.Level.String(),
 			"reference": rfcError.Reference,
 		})
+		return c.validateRootFSSubdirs(spec)
 	} else if !writable {
 		c.harness.Skip(1, "root.readonly is false but the root filesystem is still not writable")
 	}
@@ -533,6 +784,61 @@ func (c *complianceTester) validateRootFS(spec *rspec.Spec) error {
 	return nil
 }
 
+// validateRootFSSubdirs checks that a readonly root filesystem is readonly
+// below / as well, except for writable mounts on /tmp.
+func (c *complianceTester) validateRootFSSubdirs(spec *rspec.Spec) error {
+	mounted := func(dir string) (rspec.Mount, bool) {
+		for _, m := range spec.Mounts {
+			dest := filepath.Clean(m.Destination)
+			if dest == dir || strings.HasPrefix(dir, dest+"/") {
+				return m, true
+			}
+		}
+		return rspec.Mount{}, false
+	}
+
+	for _, dir := range []string{"/etc", "/usr", "/var"} {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		if _, ok := mounted(dir); ok {
+			c.harness.Skip(1, fmt.Sprintf("%s is a mount", dir))
+			continue
+		}
+		writable, err := testDirectoryWriteAccess(dir)
+		if err != nil {
+			return err
+		}
+		rfcError, err := c.Ok(!writable, specerror.RootReadonlyImplement, spec.Version, fmt.Sprintf("root filesystem is readonly at %s", dir))
+		if err != nil {
+			return err
+		}
+		c.harness.YAML(map[string]string{
+			"level":     rfcError.Level.String(),
+			"reference": rfcError.Reference,
+			"path":      dir,
+		})
+	}
+
+	if m, ok := mounted("/tmp"); ok && filepath.Clean(m.Destination) == "/tmp" {
+		readonly := false
+		for _, option := range m.Options {
+			if option == "ro" {
+				readonly = true
+			}
+		}
+		if !readonly {
+			writable, err := testDirectoryWriteAccess("/tmp")
+			if err != nil {
+				return err
+			}
+			c.harness.Ok(writable, "writable /tmp mount is writable on a readonly root filesystem")
+		}
+	}
+
+	return nil
+}
+
 func (c *complianceTester) validateRootfsPropagation(spec *rspec.Spec) error {
 	if spec.Linux == nil || spec.Linux.RootfsPropagation == "" {
 		c.harness.Skip(1, "linux.rootfsPropagation not set")
@@ -825,6 +1131,24 @@ func (c *complianceTester) validateDefaultSymlinks(spec *rspec.Spec) error {
 		}
 
 		isSymlink := fi.Mode()&os.ModeType == os.ModeSymlink
+		if symlink == "/dev/ptmx" && !isSymlink {
+			rfcError, err = c.Ok(
+				isCharDevice(fi, 5, 2),
+				specerror.DefaultRuntimeLinuxSymlinks,
+				spec.Version,
+				fmt.Sprintf("file at default symlink path %q is a symlink or the 5:2 character device", symlink))
+			if err != nil {
+				return err
+			}
+			c.harness.YAML(map[string]interface{}{
+				"level":     rfcError.Level.String(),
+				"reference": rfcError.Reference,
+				"path":      symlink,
+				"mode":      fi.Mode(),
+			})
+			continue
+		}
+
 		rfcError, err = c.Ok(
 			isSymlink,
 			specerror.DefaultRuntimeLinuxSymlinks,
@@ -867,16 +1191,23 @@ func (c *complianceTester) validateDefaultSymlinks(spec *rspec.Spec) error {
 	return nil
 }
 
-func (c *complianceTester) validateDefaultDevices(spec *rspec.Spec) error {
+// expectedDefaultDevices returns the default devices for spec, which
+// include /dev/console if a terminal is attached.
+func expectedDefaultDevices(spec *rspec.Spec) []rspec.LinuxDevice {
+	devices := make([]rspec.LinuxDevice, len(defaultDevices), len(defaultDevices)+1)
+	copy(devices, defaultDevices)
 	if spec.Process != nil && spec.Process.Terminal {
-		defaultDevices = append(defaultDevices, rspec.LinuxDevice{
+		devices = append(devices, rspec.LinuxDevice{
 			Path: "/dev/console",
 			Type: "c",
 			// FIXME: get the major/minor from the controlling TTY
 		})
 	}
+	return devices
+}
 
-	for _, device := range defaultDevices {
+func (c *complianceTester) validateDefaultDevices(spec *rspec.Spec) error {
+	for _, device := range expectedDefaultDevices(spec) {
 		err := c.validateDevice(
 			&device,
 			specerror.DefaultDevices,
@@ -890,6 +1221,75 @@ func (c *complianceTester) validateDefaultDevices(spec *rspec.Spec) error {
 	return nil
 }
 
+// allowedDevicesAnnotation enables checking that /dev holds no device nodes
+// besides the default devices and linux.devices.  Its value is a
+// comma-separated list of additional allowed device paths.
+const allowedDevicesAnnotation = "org.opencontainers.runtimetest.devices.allowed"
+
+func (c *complianceTester) validateNoExtraDevices(spec *rspec.Spec) error {
+	extra, ok := spec.Annotations[allowedDevicesAnnotation]
+	if !ok {
+		c.harness.Skip(1, fmt.Sprintf("%s not set", allowedDevicesAnnotation))
+		return nil
+	}
+
+	allowed := map[string]bool{}
+	for _, device := range expectedDefaultDevices(spec) {
+		allowed[device.Path] = true
+	}
+	if spec.Linux != nil {
+		for _, device := range spec.Linux.Devices {
+			allowed[filepath.Clean(device.Path)] = true
+		}
+	}
+	for _, path := range strings.Split(extra, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			allowed[filepath.Clean(path)] = true
+		}
+	}
+
+	var unexpected []string
+	err := filepath.Walk("/dev", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// pseudo-terminals, shared memory and message queues live
+			// on their own filesystems
+			switch path {
+			case "/dev/pts", "/dev/shm", "/dev/mqueue":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeDevice != 0 && !allowed[path] {
+			unexpected = append(unexpected, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.harness.Ok(len(unexpected) == 0, "/dev has no unexpected devices")
+	if len(unexpected) > 0 {
+		c.harness.YAML(map[string]interface{}{
+			"unexpected": unexpected,
+		})
+	}
+	return nil
+}
+
+// isCharDevice returns true if fi is the character device major:minor.
+func isCharDevice(fi os.FileInfo, major, minor uint64) bool {
+	fStat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || fStat.Mode&syscall.S_IFMT != syscall.S_IFCHR {
+		return false
+	}
+	dev := uint64(fStat.Rdev)
+	return (dev>>8)&0xfff == major && (dev&0xff)|((dev>>12)&0xfff00) == minor
+}
+
 func (c *complianceTester) validateMaskedPaths(spec *rspec.Spec) error {
 	if spec.Linux == nil || spec.Linux.MaskedPaths == nil {
 		c.harness.Skip(1, "linux.maskedPaths not set")
@@ -1051,10 +1451,13 @@ func (c *complianceTester) validateIDMappings(mappings []rspec.LinuxIDMapping, p
 		"expected": mappings,
 		"actual":   idMaps,
 	})
+	// each actual mapping may only satisfy one expected mapping
+	matched := make([]bool, len(idMaps))
 	for _, v := range mappings {
 		exist := false
-		for _, cv := range idMaps {
-			if v.HostID == cv.HostID && v.ContainerID == cv.ContainerID && v.Size == cv.Size {
+		for i, cv := range idMaps {
+			if !matched[i] && v.HostID == cv.HostID && v.ContainerID == cv.ContainerID && v.Size == cv.Size {
+				matched[i] = true
 				exist = true
 				break
 			}
@@ -1062,6 +1465,19 @@ func (c *complianceTester) validateIDMappings(mappings []rspec.LinuxIDMapping, p
 		c.harness.Ok(exist, fmt.Sprintf("%s has expected mapping %v", path, v))
 	}
 
+	var extra []rspec.LinuxIDMapping
+	for i, cv := range idMaps {
+		if !matched[i] {
+			extra = append(extra, cv)
+		}
+	}
+	c.harness.Ok(len(extra) == 0, fmt.Sprintf("%s has no unexpected mappings", path))
+	if len(extra) > 0 {
+		c.harness.YAML(map[string]interface{}{
+			"unexpected": extra,
+		})
+	}
+
 	return nil
 }
 
@@ -1269,6 +1685,7 @@ func run(context *cli.Context) error {
 	c := &complianceTester{
 		harness:         tap.New(),
 		complianceLevel: complianceLevel,
+		exec:            context.Bool("exec"),
 	}
 
 	c.harness.Header(0)
@@ -1290,8 +1707,13 @@ func run(context *cli.Context) error {
 		c.validateDefaultSymlinks,
 		c.validateDefaultFS,
 		c.validateDefaultDevices,
+		c.validateNoExtraDevices,
 		c.validateLinuxDevices,
 		c.validateLinuxProcess,
+		c.validatePIDNamespace,
+		c.validateStrictEnv,
+		c.validateAbsentEnv,
+		c.validateTerminal,
 		c.validateMaskedPaths,
 		c.validateOOMScoreAdj,
 		c.validateSeccomp,
@@ -1349,6 +1771,10 @@ func main() {
 			Value: "must",
 			Usage: "Compliance level (may, should or must)",
 		},
+		cli.BoolFlag{
+			Name:  "exec",
+			Usage: "The process was exec'ed into a running container rather than started as its init process",
+		},
 	}
 
 	app.Action = run
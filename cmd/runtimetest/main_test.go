@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectedDefaultDevices(t *testing.T) {
+	spec := &rspec.Spec{
+		Process: &rspec.Process{Terminal: true},
+	}
+	count := len(defaultDevices)
+
+	for i := 0; i < 2; i++ {
+		devices := expectedDefaultDevices(spec)
+		assert.Equal(t, count+1, len(devices))
+		assert.Equal(t, "/dev/console", devices[len(devices)-1].Path)
+	}
+	assert.Equal(t, count, len(defaultDevices))
+
+	spec.Process.Terminal = false
+	assert.Equal(t, count, len(expectedDefaultDevices(spec)))
+}
+
+func TestEnvMismatches(t *testing.T) {
+	expected := []string{"A=1", "B=2", "A=3"}
+	assert.Empty(t, envMismatches(expected, []string{"A=1", "B=2", "A=3"}))
+
+	mismatches := envMismatches(expected, []string{"A=3", "B=2"})
+	assert.Equal(t, 2, len(mismatches))
+	assert.Equal(t, 0, mismatches[0]["index"])
+	assert.Equal(t, "A=1", mismatches[0]["expected"])
+	assert.Equal(t, "A=3", mismatches[0]["actual"])
+	assert.Equal(t, 2, mismatches[1]["index"])
+	assert.Equal(t, "", mismatches[1]["actual"])
+}
+
+func TestEnvPresent(t *testing.T) {
+	env := []string{"PATH=/bin", "SENTINEL=1", "SENTINEL_OTHER=2", "EMPTY="}
+	assert.Empty(t, envPresent([]string{"HOME", "SENTINE"}, env))
+	assert.Equal(t, []string{"SENTINEL=1", "EMPTY="}, envPresent([]string{"SENTINEL", "EMPTY"}, env))
+}
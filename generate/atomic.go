@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic calls write with a temporary file in the directory of
+// path, and renames the temporary file to path once everything has been
+// written, so readers never see a partially written file.  If path is a
+// symlink, the file it points to is replaced instead.  The mode of an
+// existing file at path is preserved, and a new file gets mode 0644.
+func writeFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		path = target
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if err = write(f); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	// TempFile creates the file with mode 0600
+	if err = os.Chmod(f.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
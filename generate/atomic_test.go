@@ -0,0 +1,94 @@
+package generate
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestWriteFileAtomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a write failing halfway must leave the original file intact
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		if _, err := w.Write([]byte("part")); err != nil {
+			return err
+		}
+		return errors.New("interrupted")
+	})
+	assert.NotNil(t, err)
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "original", string(data))
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write([]byte("updated"))
+		return err
+	})
+	assert.Nil(t, err)
+	data, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "updated", string(data))
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a symlink is kept, and the file it points to is replaced
+	link := filepath.Join(dir, "link.json")
+	if err := os.Symlink(path, link); err != nil {
+		t.Fatal(err)
+	}
+	err = writeFileAtomic(link, func(w io.Writer) error {
+		_, err := w.Write([]byte("through link"))
+		return err
+	})
+	assert.Nil(t, err)
+	info, err = os.Lstat(link)
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+	data, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "through link", string(data))
+
+	// a new file is not left with the mode of the temporary file
+	created := filepath.Join(dir, "new.json")
+	assert.Nil(t, writeFileAtomic(created, func(w io.Writer) error { return nil }))
+	info, err = os.Stat(created)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestSaveToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestSaveToFile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	assert.Nil(t, g.SaveToFile(path, ExportOptions{}))
+	saved, err := NewFromFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, g.Config.Process.Args, saved.Config.Process.Args)
+}
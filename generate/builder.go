@@ -0,0 +1,182 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Builder wraps a Generator with chainable setters, like
+//
+//	spec, err := generate.NewBuilder("linux").
+//		WithHostname("web").
+//		WithArgs("sh", "-c", "echo hello").
+//		WithCapAdd("NET_ADMIN").
+//		Build()
+//
+// A setter which fails records its error and the chain goes on, so Build
+// reports the errors of all the calls at once.
+type Builder struct {
+	g    *Generator
+	errs error
+}
+
+// NewBuilder returns a Builder for the default configuration of os, see
+// New.
+func NewBuilder(os string) *Builder {
+	g, err := New(os)
+	b := &Builder{g: &g}
+	b.add("NewBuilder", err)
+	return b
+}
+
+// BuilderFor returns a Builder which changes g.
+func BuilderFor(g *Generator) *Builder {
+	return &Builder{g: g}
+}
+
+// add records err, if any, as the error of the call.
+func (b *Builder) add(call string, err error) *Builder {
+	if err != nil {
+		b.errs = multierror.Append(b.errs, fmt.Errorf("%s: %v", call, err))
+	}
+	return b
+}
+
+// Generator returns the wrapped Generator.
+func (b *Builder) Generator() *Generator {
+	return b.g
+}
+
+// Build returns a copy of the configuration, or the errors of the failed
+// calls.  It is an error to build without a configuration, or with both
+// process.args and process.commandLine outside of windows.
+func (b *Builder) Build() (rspec.Spec, error) {
+	if b.errs != nil {
+		return rspec.Spec{}, b.errs
+	}
+	if b.g == nil || b.g.Config == nil {
+		return rspec.Spec{}, fmt.Errorf("no configuration to build")
+	}
+	if err := b.g.checkProcess(); err != nil {
+		return rspec.Spec{}, err
+	}
+
+	var spec rspec.Spec
+	data, err := json.Marshal(b.g.Config)
+	if err != nil {
+		return spec, err
+	}
+	err = json.Unmarshal(data, &spec)
+	return spec, err
+}
+
+// With calls f with the wrapped Generator, for the setters the Builder
+// has no method for.
+func (b *Builder) With(f func(g *Generator) error) *Builder {
+	return b.add("With", f(b.g))
+}
+
+// WithHostname sets the hostname, see SetHostname.
+func (b *Builder) WithHostname(hostname string) *Builder {
+	return b.add("WithHostname", b.g.SetHostname(hostname))
+}
+
+// WithRootPath sets the path of the root filesystem, see SetRootPath.
+func (b *Builder) WithRootPath(path string) *Builder {
+	return b.add("WithRootPath", b.g.SetRootPath(path))
+}
+
+// WithRootReadonly sets whether the root filesystem is read-only.
+func (b *Builder) WithRootReadonly(readonly bool) *Builder {
+	b.g.SetRootReadonly(readonly)
+	return b
+}
+
+// WithAnnotation adds an annotation.
+func (b *Builder) WithAnnotation(key, value string) *Builder {
+	b.g.AddAnnotation(key, value)
+	return b
+}
+
+// WithArgs sets the arguments of the process.
+func (b *Builder) WithArgs(args ...string) *Builder {
+	b.g.SetProcessArgs(args)
+	return b
+}
+
+// WithCwd sets the working directory of the process.
+func (b *Builder) WithCwd(cwd string) *Builder {
+	b.g.SetProcessCwd(cwd)
+	return b
+}
+
+// WithEnv adds an environment variable of the process, see AddProcessEnv.
+func (b *Builder) WithEnv(name, value string) *Builder {
+	return b.add("WithEnv", b.g.AddProcessEnv(name, value))
+}
+
+// WithUser sets the uid and gid of the process.
+func (b *Builder) WithUser(uid, gid uint32) *Builder {
+	b.g.SetProcessUID(uid)
+	b.g.SetProcessGID(gid)
+	return b
+}
+
+// capabilityName adds the CAP_ prefix to a capability name without it.
+func capabilityName(c string) string {
+	c = strings.ToUpper(c)
+	if !strings.HasPrefix(c, "CAP_") {
+		c = "CAP_" + c
+	}
+	return c
+}
+
+// WithCapAdd adds a capability, with or without the CAP_ prefix, to all 5
+// capability sets.
+func (b *Builder) WithCapAdd(c string) *Builder {
+	return b.add("WithCapAdd", b.g.AddProcessCapability(capabilityName(c)))
+}
+
+// WithCapDrop drops a capability, with or without the CAP_ prefix, from
+// all 5 capability sets.
+func (b *Builder) WithCapDrop(c string) *Builder {
+	return b.add("WithCapDrop", b.g.DropProcessCapability(capabilityName(c)))
+}
+
+// WithMount adds a mount, see AddMount.
+func (b *Builder) WithMount(mnt rspec.Mount) *Builder {
+	b.g.AddMount(mnt)
+	return b
+}
+
+// WithBindMount adds a bind mount, see AddBindMount.
+func (b *Builder) WithBindMount(source, dest string, options ...string) *Builder {
+	return b.add("WithBindMount", b.g.AddBindMount(source, dest, options))
+}
+
+// WithNamespace adds or replaces a namespace, see
+// AddOrReplaceLinuxNamespace.
+func (b *Builder) WithNamespace(ns, path string) *Builder {
+	return b.add("WithNamespace", b.g.AddOrReplaceLinuxNamespace(ns, path))
+}
+
+// WithCgroupsPath sets the cgroups path.
+func (b *Builder) WithCgroupsPath(path string) *Builder {
+	b.g.SetLinuxCgroupsPath(path)
+	return b
+}
+
+// WithMemoryLimit sets the memory limit in bytes.
+func (b *Builder) WithMemoryLimit(limit int64) *Builder {
+	b.g.SetLinuxResourcesMemoryLimit(limit)
+	return b
+}
+
+// WithCPUs sets the CPUs of the cpuset, see SetLinuxResourcesCPUCpus.
+func (b *Builder) WithCPUs(cpus string) *Builder {
+	return b.add("WithCPUs", b.g.SetLinuxResourcesCPUCpus(cpus))
+}
@@ -0,0 +1,72 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// CapabilitiesProfileDefault is the name of the default capability
+	// profile, which matches the capabilities Docker grants by default.
+	CapabilitiesProfileDefault = "default"
+	// CapabilitiesProfileNone is the name of the empty capability profile.
+	CapabilitiesProfileNone = "none"
+)
+
+// capabilitiesProfiles maps the profile names to their capabilities.
+var capabilitiesProfiles = map[string][]string{
+	CapabilitiesProfileDefault: {
+		"CAP_CHOWN",
+		"CAP_DAC_OVERRIDE",
+		"CAP_FSETID",
+		"CAP_FOWNER",
+		"CAP_MKNOD",
+		"CAP_NET_RAW",
+		"CAP_SETGID",
+		"CAP_SETUID",
+		"CAP_SETFCAP",
+		"CAP_SETPCAP",
+		"CAP_NET_BIND_SERVICE",
+		"CAP_SYS_CHROOT",
+		"CAP_KILL",
+		"CAP_AUDIT_WRITE",
+	},
+	CapabilitiesProfileNone: {},
+}
+
+// CapabilitiesProfile returns the capabilities of the named profile.
+func CapabilitiesProfile(name string) ([]string, error) {
+	caps, ok := capabilitiesProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown capabilities profile %q, must be one of %v", name, CapabilitiesProfiles())
+	}
+	return append([]string{}, caps...), nil
+}
+
+// CapabilitiesProfiles returns the sorted names of the known capability profiles.
+func CapabilitiesProfiles() []string {
+	names := make([]string, 0, len(capabilitiesProfiles))
+	for name := range capabilitiesProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetCapabilitiesProfile replaces the bounding, effective and permitted
+// sets of g.Config.Process.Capabilities with the capabilities of the named
+// profile, and clears the inheritable and ambient sets, as Docker does.
+func (g *Generator) SetCapabilitiesProfile(name string) error {
+	caps, err := CapabilitiesProfile(name)
+	if err != nil {
+		return err
+	}
+
+	g.initConfigProcessCapabilities()
+	g.Config.Process.Capabilities.Bounding = caps
+	g.Config.Process.Capabilities.Effective = append([]string{}, caps...)
+	g.Config.Process.Capabilities.Inheritable = []string{}
+	g.Config.Process.Capabilities.Permitted = append([]string{}, caps...)
+	g.Config.Process.Capabilities.Ambient = []string{}
+	return nil
+}
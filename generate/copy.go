@@ -0,0 +1,91 @@
+package generate
+
+import (
+	"encoding/json"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+// copyProcess returns a deep copy of process.
+func copyProcess(process *rspec.Process) *rspec.Process {
+	if process == nil {
+		return nil
+	}
+
+	p := *process
+	if process.ConsoleSize != nil {
+		consoleSize := *process.ConsoleSize
+		p.ConsoleSize = &consoleSize
+	}
+	if process.User.Umask != nil {
+		umask := *process.User.Umask
+		p.User.Umask = &umask
+	}
+	if process.User.AdditionalGids != nil {
+		p.User.AdditionalGids = append([]uint32{}, process.User.AdditionalGids...)
+	}
+	p.Args = copyStrings(process.Args)
+	p.Env = copyStrings(process.Env)
+	if process.Capabilities != nil {
+		p.Capabilities = &rspec.LinuxCapabilities{
+			Bounding:    copyStrings(process.Capabilities.Bounding),
+			Effective:   copyStrings(process.Capabilities.Effective),
+			Inheritable: copyStrings(process.Capabilities.Inheritable),
+			Permitted:   copyStrings(process.Capabilities.Permitted),
+			Ambient:     copyStrings(process.Capabilities.Ambient),
+		}
+	}
+	if process.Rlimits != nil {
+		p.Rlimits = append([]rspec.POSIXRlimit{}, process.Rlimits...)
+	}
+	if process.OOMScoreAdj != nil {
+		oomScoreAdj := *process.OOMScoreAdj
+		p.OOMScoreAdj = &oomScoreAdj
+	}
+
+	return &p
+}
+
+// copyLinux returns a deep copy of linux.  rspec.Linux has too many nested
+// pointers to copy by hand, so this round-trips it through JSON.
+func copyLinux(linux *rspec.Linux) (*rspec.Linux, error) {
+	if linux == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(linux)
+	if err != nil {
+		return nil, err
+	}
+	var l rspec.Linux
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// copyHooks returns a deep copy of hooks.
+func copyHooks(hooks []rspec.Hook) []rspec.Hook {
+	if hooks == nil {
+		return nil
+	}
+
+	h := make([]rspec.Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		hook.Args = copyStrings(hook.Args)
+		hook.Env = copyStrings(hook.Env)
+		if hook.Timeout != nil {
+			timeout := *hook.Timeout
+			hook.Timeout = &timeout
+		}
+		h = append(h, hook)
+	}
+	return h
+}
@@ -0,0 +1,74 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cpuRange is a range of CPUs or memory nodes in a cpulist.
+type cpuRange struct {
+	first, last int
+}
+
+// parseCPU parses a CPU or memory node number of a cpulist.
+func parseCPU(s string) (int, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return strconv.Atoi(s)
+}
+
+// parseCPURanges parses a list of CPUs or memory nodes in the cpulist
+// format, without expanding its ranges.
+func parseCPURanges(s string) ([]cpuRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	ranges := []cpuRange{}
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := parseCPU(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpulist %q: %v", s, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = parseCPU(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid cpulist %q: %v", s, err)
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid cpulist %q: range %s is reversed", s, part)
+			}
+		}
+		ranges = append(ranges, cpuRange{first: first, last: last})
+	}
+	return ranges, nil
+}
+
+// ParseCPUList parses a list of CPUs or memory nodes in the cpulist
+// format of cpuset.cpus and Cpus_allowed_list, like "0-3,7", and returns
+// the sorted numbers in it.  An empty list gives no numbers.  The ranges
+// are expanded, so only use it on lists of CPUs which exist.
+func ParseCPUList(s string) ([]int, error) {
+	ranges, err := parseCPURanges(s)
+	if err != nil || ranges == nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	cpus := []int{}
+	for _, r := range ranges {
+		for cpu := r.first; cpu <= r.last; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
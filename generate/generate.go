@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
 	"github.com/opencontainers/runtime-tools/generate/seccomp"
 	"github.com/opencontainers/runtime-tools/validate"
 	"github.com/syndtr/gocapability/capability"
@@ -18,20 +23,25 @@ var (
 	// Namespaces include the names of supported namespaces.
 	Namespaces = []string{"network", "pid", "mount", "ipc", "uts", "user", "cgroup"}
 
-	// we don't care about order...and this is way faster...
-	removeFunc = func(s []string, i int) []string {
-		s[i] = s[len(s)-1]
-		return s[:len(s)-1]
-	}
+	// SpecVersions are the released versions of the runtime specification
+	// SetOCIVersion accepts.
+	SpecVersions = []string{"1.0.0", "1.0.1", "1.0.2"}
 )
 
 // Generator represents a generator for a container config.
 type Generator struct {
 	Config       *rspec.Spec
 	HostSpecific bool
+	// BundleDir is the bundle directory, which SetRootPath checks the root
+	// path against if HostSpecific is set.  If empty, the current
+	// directory is used.
+	BundleDir string
 	// This is used to keep a cache of the ENVs added to improve
 	// performance when adding a huge number of ENV variables
 	envMap map[string]int
+	// envExpansion enables the expansion of host environment variables
+	// in the values of added environment variables
+	envExpansion bool
 }
 
 // ExportOptions have toggles for exporting only certain parts of the specification
@@ -303,7 +313,8 @@ func NewFromTemplate(r io.Reader) (Generator, error) {
 func createEnvCacheMap(env []string) map[string]int {
 	envMap := make(map[string]int, len(env))
 	for i, val := range env {
-		envMap[val] = i
+		split := strings.SplitN(val, "=", 2)
+		envMap[split[0]] = i
 	}
 	return envMap
 }
@@ -324,7 +335,43 @@ func (g *Generator) Spec() *rspec.Spec {
 	return g.Config
 }
 
-// Save writes the configuration into w.
+// Process returns a deep copy of g.Config.Process, or nil if it is not set.
+// Together with the SetProcess* and AddProcess* methods this allows building
+// a standalone process configuration, e.g. for exec'ing into a container.
+func (g *Generator) Process() *rspec.Process {
+	if g.Config == nil {
+		return nil
+	}
+	return copyProcess(g.Config.Process)
+}
+
+// SetProcess sets g.Config.Process to a deep copy of process, replacing
+// the whole process block. The process must have non-empty args.
+func (g *Generator) SetProcess(process *rspec.Process) error {
+	if process == nil || len(process.Args) == 0 {
+		return fmt.Errorf("process args must not be empty")
+	}
+	g.initConfig()
+	g.Config.Process = copyProcess(process)
+	g.envMap = createEnvCacheMap(g.Config.Process.Env)
+	return nil
+}
+
+// SetLinux sets g.Config.Linux to a deep copy of linux, replacing the whole
+// linux block.
+func (g *Generator) SetLinux(linux *rspec.Linux) error {
+	l, err := copyLinux(linux)
+	if err != nil {
+		return err
+	}
+	g.initConfig()
+	g.Config.Linux = l
+	return nil
+}
+
+// Save writes the configuration into w.  It returns an error if
+// process.args and process.commandLine are both set in a configuration
+// which is not for windows.
 func (g *Generator) Save(w io.Writer, exportOpts ExportOptions) (err error) {
 	var data []byte
 
@@ -341,6 +388,9 @@ func (g *Generator) Save(w io.Writer, exportOpts ExportOptions) (err error) {
 	if exportOpts.Seccomp {
 		data, err = json.MarshalIndent(g.Config.Linux.Seccomp, "", "\t")
 	} else {
+		if err := g.checkProcess(); err != nil {
+			return err
+		}
 		data, err = json.MarshalIndent(g.Config, "", "\t")
 	}
 	if err != nil {
@@ -355,14 +405,34 @@ func (g *Generator) Save(w io.Writer, exportOpts ExportOptions) (err error) {
 	return nil
 }
 
-// SaveToFile writes the configuration into a file.
-func (g *Generator) SaveToFile(path string, exportOpts ExportOptions) error {
-	f, err := os.Create(path)
+// String returns the configuration formatted like Save does, without
+// changing it.  It is meant for debugging and logging.
+func (g *Generator) String() string {
+	if g.Config == nil {
+		return "null"
+	}
+
+	spec := *g.Config
+	if spec.Linux != nil {
+		if buf, err := json.Marshal(spec.Linux); err == nil && string(buf) == "{}" {
+			spec.Linux = nil
+		}
+	}
+
+	data, err := json.MarshalIndent(&spec, "", "\t")
 	if err != nil {
-		return err
+		return fmt.Sprintf("invalid configuration: %v", err)
 	}
-	defer f.Close()
-	return g.Save(f, exportOpts)
+	return string(data)
+}
+
+// SaveToFile writes the configuration into a file.  The file is replaced
+// atomically, keeping the mode of an existing file, and a symlink is
+// followed rather than replaced.
+func (g *Generator) SaveToFile(path string, exportOpts ExportOptions) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return g.Save(w, exportOpts)
+	})
 }
 
 // SetVersion sets g.Config.Version.
@@ -371,10 +441,72 @@ func (g *Generator) SetVersion(version string) {
 	g.Config.Version = version
 }
 
-// SetRootPath sets g.Config.Root.Path.
-func (g *Generator) SetRootPath(path string) {
+// SetRootPath sets g.Config.Root.Path.  The path is cleaned, and if
+// g.HostSpecific is set it must be an existing directory in the bundle
+// g.BundleDir, either absolute or relative to the bundle.  The path must
+// not lead out of the bundle, with .. or through symbolic links.
+func (g *Generator) SetRootPath(path string) error {
+	if path != "" {
+		path = filepath.Clean(path)
+	}
+	if g.HostSpecific {
+		if err := g.rootPathInBundle(path); err != nil {
+			return err
+		}
+	}
+
 	g.initConfigRoot()
 	g.Config.Root.Path = path
+	return nil
+}
+
+// rootPathInBundle returns an error unless path, with its symbolic links
+// resolved, is an existing directory which is the bundle directory or
+// below it.
+func (g *Generator) rootPathInBundle(path string) error {
+	bundle := g.BundleDir
+	if bundle == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		bundle = cwd
+	}
+	bundle, err := filepath.Abs(bundle)
+	if err != nil {
+		return err
+	}
+	if bundle, err = filepath.EvalSymlinks(bundle); err != nil {
+		return err
+	}
+
+	target := path
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(bundle, target)
+	}
+	fi, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("cannot find the root path %q: %v", path, err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("root path %q is not a directory", path)
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return err
+	}
+	if resolved != bundle && !isSubpath(resolved, bundle) {
+		return fmt.Errorf("root path %q leads to %s, outside of the bundle %s", path, resolved, bundle)
+	}
+	return nil
+}
+
+// GetRootPath returns g.Config.Root.Path.
+func (g *Generator) GetRootPath() string {
+	if g.Config == nil || g.Config.Root == nil {
+		return ""
+	}
+	return g.Config.Root.Path
 }
 
 // SetRootReadonly sets g.Config.Root.Readonly.
@@ -383,16 +515,56 @@ func (g *Generator) SetRootReadonly(b bool) {
 	g.Config.Root.Readonly = b
 }
 
+// GetRootReadonly returns g.Config.Root.Readonly.
+func (g *Generator) GetRootReadonly() bool {
+	if g.Config == nil || g.Config.Root == nil {
+		return false
+	}
+	return g.Config.Root.Readonly
+}
+
 // SetHostname sets g.Config.Hostname.
-func (g *Generator) SetHostname(s string) {
+func (g *Generator) SetHostname(s string) error {
+	if err := hostnameValid(s); err != nil {
+		return err
+	}
 	g.initConfig()
 	g.Config.Hostname = s
+	return nil
+}
+
+// maxHostnameLen is the length limit of the kernel for UTS names.
+const maxHostnameLen = 64
+
+// hostnameLabelRegexp matches a valid RFC 1123 hostname label.
+var hostnameLabelRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// hostnameValid checks that s is a valid RFC 1123 hostname which the
+// kernel accepts.  An empty hostname leaves the hostname unset.
+func hostnameValid(s string) error {
+	if s == "" {
+		return nil
+	}
+	if len(s) > maxHostnameLen {
+		return fmt.Errorf("hostname %q is longer than %d characters", s, maxHostnameLen)
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !hostnameLabelRegexp.MatchString(label) {
+			return fmt.Errorf("hostname %q is not a valid RFC 1123 hostname", s)
+		}
+	}
+	return nil
 }
 
-// SetOCIVersion sets g.Config.Version.
-func (g *Generator) SetOCIVersion(s string) {
+// SetOCIVersion sets g.Config.Version to one of SpecVersions, or to the
+// version of the specification this package supports, rspec.Version.
+func (g *Generator) SetOCIVersion(s string) error {
+	if s != rspec.Version && !isReleasedVersion(s) {
+		return fmt.Errorf("unknown runtime specification version %q, must be one of %s or %s", s, strings.Join(SpecVersions, ", "), rspec.Version)
+	}
 	g.initConfig()
 	g.Config.Version = s
+	return nil
 }
 
 // ClearAnnotations clears g.Config.Annotations.
@@ -409,6 +581,31 @@ func (g *Generator) AddAnnotation(key, value string) {
 	g.Config.Annotations[key] = value
 }
 
+// AddAnnotationsFromFile reads a JSON object of annotations from path and
+// adds them into g.Config.Annotations, replacing any existing values.
+func (g *Generator) AddAnnotationsFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var annotations map[string]string
+	if err := json.NewDecoder(f).Decode(&annotations); err != nil {
+		return fmt.Errorf("invalid annotations file %s: %v", path, err)
+	}
+	for key := range annotations {
+		if key == "" {
+			return fmt.Errorf("annotation in %s has an empty key", path)
+		}
+	}
+
+	for key, value := range annotations {
+		g.AddAnnotation(key, value)
+	}
+	return nil
+}
+
 // RemoveAnnotation remove an annotation from g.Config.Annotations.
 func (g *Generator) RemoveAnnotation(key string) {
 	if g.Config == nil || g.Config.Annotations == nil {
@@ -457,6 +654,25 @@ func (g *Generator) SetProcessGID(gid uint32) {
 	g.Config.Process.User.GID = gid
 }
 
+// SetProcessUser replaces g.Config.Process.User with a user of the given
+// uid, gid, additional gids and umask, which is not set if nil.  The
+// additional gids are deduplicated and sorted like with
+// AddProcessAdditionalGid.
+func (g *Generator) SetProcessUser(uid, gid uint32, additionalGids []uint32, umask *uint32) {
+	g.initConfigProcess()
+	g.Config.Process.User = rspec.User{
+		UID: uid,
+		GID: gid,
+	}
+	if umask != nil {
+		u := *umask
+		g.Config.Process.User.Umask = &u
+	}
+	for _, group := range additionalGids {
+		g.AddProcessAdditionalGid(group)
+	}
+}
+
 // SetProcessCwd sets g.Config.Process.Cwd.
 func (g *Generator) SetProcessCwd(cwd string) {
 	g.initConfigProcess()
@@ -487,6 +703,26 @@ func (g *Generator) SetProcessArgs(args []string) {
 	g.Config.Process.Args = args
 }
 
+// SetProcessCommandLine sets g.Config.Process.CommandLine, the full command
+// line run on Windows, and clears g.Config.Process.Args, which it replaces.
+// The configuration must be for Windows.
+func (g *Generator) SetProcessCommandLine(cmdline string) error {
+	if g.Config == nil || g.Config.Windows == nil {
+		return fmt.Errorf("process.commandLine is only supported on windows")
+	}
+	g.initConfigProcess()
+	g.Config.Process.CommandLine = cmdline
+	g.Config.Process.Args = nil
+	return nil
+}
+
+// AddProcessArgs appends args to g.Config.Process.Args, e.g. to add flags
+// to the command of a template.
+func (g *Generator) AddProcessArgs(args ...string) {
+	g.initConfigProcess()
+	g.Config.Process.Args = append(g.Config.Process.Args, args...)
+}
+
 // ClearProcessEnv clears g.Config.Process.Env.
 func (g *Generator) ClearProcessEnv() {
 	if g.Config == nil || g.Config.Process == nil {
@@ -497,32 +733,104 @@ func (g *Generator) ClearProcessEnv() {
 	g.envMap = map[string]int{}
 }
 
+// envNameRegexp matches valid environment variable names.
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func envNameValid(name string) error {
+	if !envNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return nil
+}
+
+// SetEnvExpansion sets whether AddProcessEnv, AddMultipleProcessEnv and
+// AddProcessEnvMap expand $VAR and ${VAR} in values from the environment
+// of the generating process.  Undefined variables expand to the empty
+// string, and $$ gives a literal $.  Expansion is disabled by default, so
+// values are taken literally.
+func (g *Generator) SetEnvExpansion(expand bool) {
+	g.envExpansion = expand
+}
+
+// expandEnv expands value if g.envExpansion is set.
+func (g *Generator) expandEnv(value string) string {
+	if !g.envExpansion {
+		return value
+	}
+	return os.Expand(value, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		return os.Getenv(name)
+	})
+}
+
 // AddProcessEnv adds name=value into g.Config.Process.Env, or replaces an
-// existing entry with the given name.
-func (g *Generator) AddProcessEnv(name, value string) {
-	if name == "" {
-		return
+// existing entry with the given name. The value may be empty, the name must
+// match [A-Za-z_][A-Za-z0-9_]*.
+func (g *Generator) AddProcessEnv(name, value string) error {
+	if err := envNameValid(name); err != nil {
+		return err
 	}
 
 	g.initConfigProcess()
-	g.addEnv(fmt.Sprintf("%s=%s", name, value), name)
+	g.addEnv(fmt.Sprintf("%s=%s", name, g.expandEnv(value)), name)
+	return nil
 }
 
 // AddMultipleProcessEnv adds multiple name=value into g.Config.Process.Env, or replaces
-// existing entries with the given name.
-func (g *Generator) AddMultipleProcessEnv(envs []string) {
+// existing entries with the given name. If one of the entries is malformed,
+// none of them are added.
+func (g *Generator) AddMultipleProcessEnv(envs []string) error {
+	for _, val := range envs {
+		split := strings.SplitN(val, "=", 2)
+		if len(split) != 2 {
+			return fmt.Errorf("environment variable %q must contain '='", val)
+		}
+		if err := envNameValid(split[0]); err != nil {
+			return err
+		}
+	}
+
 	g.initConfigProcess()
 
 	for _, val := range envs {
 		split := strings.SplitN(val, "=", 2)
-		g.addEnv(val, split[0])
+		g.addEnv(fmt.Sprintf("%s=%s", split[0], g.expandEnv(split[1])), split[0])
+	}
+	return nil
+}
+
+// AddProcessEnvMap adds the variables of env into g.Config.Process.Env, or
+// replaces existing entries with the same name. New variables are appended
+// in the sorted order of their names. If one of the names is invalid, none
+// of them are added.
+func (g *Generator) AddProcessEnvMap(env map[string]string) error {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if err := envNameValid(name); err != nil {
+			return err
+		}
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	g.initConfigProcess()
+
+	for _, name := range names {
+		g.addEnv(fmt.Sprintf("%s=%s", name, g.expandEnv(env[name])), name)
+	}
+	return nil
 }
 
 // addEnv looks through adds ENV to the Process and checks envMap for
 // any duplicates
-// This is called by both AddMultipleProcessEnv and AddProcessEnv
+// This is called by AddMultipleProcessEnv, AddProcessEnvMap and AddProcessEnv
 func (g *Generator) addEnv(env, key string) {
+	if g.envMap == nil {
+		// e.g. a Generator built as Generator{Config: config}
+		g.envMap = createEnvCacheMap(g.Config.Process.Env)
+	}
 	if idx, ok := g.envMap[key]; ok {
 		// The ENV exists in the cache, so change its value in g.Config.Process.Env
 		g.Config.Process.Env[idx] = env
@@ -533,14 +841,34 @@ func (g *Generator) addEnv(env, key string) {
 	}
 }
 
-// AddProcessRlimits adds rlimit into g.Config.Process.Rlimits.
-func (g *Generator) AddProcessRlimits(rType string, rHard uint64, rSoft uint64) {
+// rlimitType returns the RLIMIT_* name of an rlimit type, which may also be
+// given in the short form of ulimit and docker, like nofile.
+func rlimitType(name string) (string, error) {
+	rType := strings.ToUpper(name)
+	if !strings.HasPrefix(rType, "RLIMIT_") {
+		rType = "RLIMIT_" + rType
+	}
+	if _, err := validate.RlimitFromString(rType); err != nil {
+		return "", fmt.Errorf("unknown rlimit type %q", name)
+	}
+	return rType, nil
+}
+
+// AddProcessRlimits adds rlimit into g.Config.Process.Rlimits, or replaces
+// the limits of an existing rlimit of the same type.  The type may be
+// given as RLIMIT_NOFILE or in the short form nofile.
+func (g *Generator) AddProcessRlimits(rType string, rHard uint64, rSoft uint64) error {
+	rType, err := rlimitType(rType)
+	if err != nil {
+		return err
+	}
+
 	g.initConfigProcess()
 	for i, rlimit := range g.Config.Process.Rlimits {
 		if rlimit.Type == rType {
 			g.Config.Process.Rlimits[i].Hard = rHard
 			g.Config.Process.Rlimits[i].Soft = rSoft
-			return
+			return nil
 		}
 	}
 
@@ -550,13 +878,18 @@ func (g *Generator) AddProcessRlimits(rType string, rHard uint64, rSoft uint64)
 		Soft: rSoft,
 	}
 	g.Config.Process.Rlimits = append(g.Config.Process.Rlimits, newRlimit)
+	return nil
 }
 
-// RemoveProcessRlimits removes a rlimit from g.Config.Process.Rlimits.
+// RemoveProcessRlimits removes a rlimit from g.Config.Process.Rlimits.  The
+// type may be given in the short form like for AddProcessRlimits.
 func (g *Generator) RemoveProcessRlimits(rType string) {
 	if g.Config == nil || g.Config.Process == nil {
 		return
 	}
+	if t, err := rlimitType(rType); err == nil {
+		rType = t
+	}
 	for i, rlimit := range g.Config.Process.Rlimits {
 		if rlimit.Type == rType {
 			g.Config.Process.Rlimits = append(g.Config.Process.Rlimits[:i], g.Config.Process.Rlimits[i+1:]...)
@@ -582,6 +915,7 @@ func (g *Generator) ClearProcessAdditionalGids() {
 }
 
 // AddProcessAdditionalGid adds an additional gid into g.Config.Process.AdditionalGids.
+// Duplicates are ignored and the gids are kept sorted.
 func (g *Generator) AddProcessAdditionalGid(gid uint32) {
 	g.initConfigProcess()
 	for _, group := range g.Config.Process.User.AdditionalGids {
@@ -589,7 +923,22 @@ func (g *Generator) AddProcessAdditionalGid(gid uint32) {
 			return
 		}
 	}
-	g.Config.Process.User.AdditionalGids = append(g.Config.Process.User.AdditionalGids, gid)
+	gids := append(g.Config.Process.User.AdditionalGids, gid)
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	g.Config.Process.User.AdditionalGids = gids
+}
+
+// RemoveProcessAdditionalGid removes a gid from g.Config.Process.AdditionalGids.
+func (g *Generator) RemoveProcessAdditionalGid(gid uint32) {
+	if g.Config == nil || g.Config.Process == nil {
+		return
+	}
+	for i, group := range g.Config.Process.User.AdditionalGids {
+		if group == gid {
+			g.Config.Process.User.AdditionalGids = append(g.Config.Process.User.AdditionalGids[:i], g.Config.Process.User.AdditionalGids[i+1:]...)
+			return
+		}
+	}
 }
 
 // SetProcessSelinuxLabel sets g.Config.Process.SelinuxLabel.
@@ -604,16 +953,77 @@ func (g *Generator) SetLinuxCgroupsPath(path string) {
 	g.Config.Linux.CgroupsPath = path
 }
 
+// SetLinuxCgroupsPathSystemd sets g.Config.Linux.CgroupsPath to the
+// "slice:prefix:name" form used with the systemd cgroup driver.
+func (g *Generator) SetLinuxCgroupsPathSystemd(slice, prefix, name string) error {
+	for _, part := range []string{slice, prefix, name} {
+		if strings.Contains(part, ":") {
+			return fmt.Errorf("systemd cgroups path element %q must not contain ':'", part)
+		}
+	}
+	path := strings.Join([]string{slice, prefix, name}, ":")
+	if _, err := cgroups.SystemdToCgroupfsPath(path); err != nil {
+		return err
+	}
+
+	g.SetLinuxCgroupsPath(path)
+	return nil
+}
+
 // SetLinuxIntelRdtL3CacheSchema sets g.Config.Linux.IntelRdt.L3CacheSchema
 func (g *Generator) SetLinuxIntelRdtL3CacheSchema(schema string) {
 	g.initConfigLinuxIntelRdt()
 	g.Config.Linux.IntelRdt.L3CacheSchema = schema
 }
 
-// SetLinuxMountLabel sets g.Config.Linux.MountLabel.
-func (g *Generator) SetLinuxMountLabel(label string) {
+var (
+	// selinuxNameRegexp matches the user, role and type of an SELinux
+	// context.
+	selinuxNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	// selinuxLevelRegexp matches the MLS/MCS level or range of an SELinux
+	// context, like s0, s0:c1,c2 or s0-s0:c0.c1023.
+	selinuxLevelRegexp = regexp.MustCompile(`^s[0-9]+(:c[0-9]+([.,]c[0-9]+)*)?(-s[0-9]+(:c[0-9]+([.,]c[0-9]+)*)?)?$`)
+)
+
+// selinuxContextValid returns an error if label looks like an SELinux
+// context, by containing a colon, but is not of the form
+// user:role:type[:level].  Other labels are not checked.
+func selinuxContextValid(label string) error {
+	if !strings.Contains(label, ":") {
+		return nil
+	}
+	fields := strings.SplitN(label, ":", 4)
+	if len(fields) < 3 {
+		return fmt.Errorf("invalid SELinux context %q, must be user:role:type[:level]", label)
+	}
+	for i, name := range []string{"user", "role", "type"} {
+		if !selinuxNameRegexp.MatchString(fields[i]) {
+			return fmt.Errorf("invalid SELinux context %q, bad %s %q", label, name, fields[i])
+		}
+	}
+	if len(fields) == 4 && !selinuxLevelRegexp.MatchString(fields[3]) {
+		return fmt.Errorf("invalid SELinux context %q, bad level %q", label, fields[3])
+	}
+	return nil
+}
+
+// SetLinuxMountLabel sets g.Config.Linux.MountLabel.  A label which looks
+// like an SELinux context must be of the form user:role:type[:level].
+func (g *Generator) SetLinuxMountLabel(label string) error {
+	if err := selinuxContextValid(label); err != nil {
+		return err
+	}
 	g.initConfigLinux()
 	g.Config.Linux.MountLabel = label
+	return nil
+}
+
+// GetLinuxMountLabel returns g.Config.Linux.MountLabel.
+func (g *Generator) GetLinuxMountLabel() string {
+	if g.Config == nil || g.Config.Linux == nil {
+		return ""
+	}
+	return g.Config.Linux.MountLabel
 }
 
 // SetProcessOOMScoreAdj sets g.Config.Process.OOMScoreAdj.
@@ -808,19 +1218,30 @@ func (g *Generator) SetLinuxResourcesCPURealtimePeriod(period uint64) {
 	g.Config.Linux.Resources.CPU.RealtimePeriod = &period
 }
 
-// SetLinuxResourcesCPUCpus sets g.Config.Linux.Resources.CPU.Cpus.
-func (g *Generator) SetLinuxResourcesCPUCpus(cpus string) {
+// SetLinuxResourcesCPUCpus sets g.Config.Linux.Resources.CPU.Cpus.  The
+// CPUs must be a cpulist, see ParseCPUList.
+func (g *Generator) SetLinuxResourcesCPUCpus(cpus string) error {
+	if _, err := parseCPURanges(cpus); err != nil {
+		return err
+	}
 	g.InitConfigLinuxResourcesCPU()
 	g.Config.Linux.Resources.CPU.Cpus = cpus
+	return nil
 }
 
-// SetLinuxResourcesCPUMems sets g.Config.Linux.Resources.CPU.Mems.
-func (g *Generator) SetLinuxResourcesCPUMems(mems string) {
+// SetLinuxResourcesCPUMems sets g.Config.Linux.Resources.CPU.Mems.  The
+// memory nodes must be a cpulist, see ParseCPUList.
+func (g *Generator) SetLinuxResourcesCPUMems(mems string) error {
+	if _, err := parseCPURanges(mems); err != nil {
+		return err
+	}
 	g.InitConfigLinuxResourcesCPU()
 	g.Config.Linux.Resources.CPU.Mems = mems
+	return nil
 }
 
 // AddLinuxResourcesHugepageLimit adds or sets g.Config.Linux.Resources.HugepageLimits.
+// The limit replaces any existing limits for the same page size.
 func (g *Generator) AddLinuxResourcesHugepageLimit(pageSize string, limit uint64) {
 	hugepageLimit := rspec.LinuxHugepageLimit{
 		Pagesize: pageSize,
@@ -830,25 +1251,33 @@ func (g *Generator) AddLinuxResourcesHugepageLimit(pageSize string, limit uint64
 	g.initConfigLinuxResources()
 	for i, pageLimit := range g.Config.Linux.Resources.HugepageLimits {
 		if pageLimit.Pagesize == pageSize {
-			g.Config.Linux.Resources.HugepageLimits[i].Limit = limit
+			// a template may hold several limits for the same page size
+			hugepageLimits := append(g.Config.Linux.Resources.HugepageLimits[:i], hugepageLimit)
+			for _, pageLimit := range g.Config.Linux.Resources.HugepageLimits[i+1:] {
+				if pageLimit.Pagesize != pageSize {
+					hugepageLimits = append(hugepageLimits, pageLimit)
+				}
+			}
+			g.Config.Linux.Resources.HugepageLimits = hugepageLimits
 			return
 		}
 	}
 	g.Config.Linux.Resources.HugepageLimits = append(g.Config.Linux.Resources.HugepageLimits, hugepageLimit)
 }
 
-// DropLinuxResourcesHugepageLimit drops a hugepage limit from g.Config.Linux.Resources.HugepageLimits.
+// DropLinuxResourcesHugepageLimit drops all hugepage limits for a page size from g.Config.Linux.Resources.HugepageLimits.
 func (g *Generator) DropLinuxResourcesHugepageLimit(pageSize string) {
 	if g.Config == nil || g.Config.Linux == nil || g.Config.Linux.Resources == nil {
 		return
 	}
 
-	for i, pageLimit := range g.Config.Linux.Resources.HugepageLimits {
-		if pageLimit.Pagesize == pageSize {
-			g.Config.Linux.Resources.HugepageLimits = append(g.Config.Linux.Resources.HugepageLimits[:i], g.Config.Linux.Resources.HugepageLimits[i+1:]...)
-			return
+	hugepageLimits := g.Config.Linux.Resources.HugepageLimits[:0]
+	for _, pageLimit := range g.Config.Linux.Resources.HugepageLimits {
+		if pageLimit.Pagesize != pageSize {
+			hugepageLimits = append(hugepageLimits, pageLimit)
 		}
 	}
+	g.Config.Linux.Resources.HugepageLimits = hugepageLimits
 }
 
 // SetLinuxResourcesMemoryLimit sets g.Config.Linux.Resources.Memory.Limit.
@@ -928,10 +1357,20 @@ func (g *Generator) DropLinuxResourcesNetworkPriorities(name string) {
 	}
 }
 
+// PidsUnlimited is the pids limit which removes the limit on the number of
+// processes in the cgroup.
+const PidsUnlimited int64 = -1
+
 // SetLinuxResourcesPidsLimit sets g.Config.Linux.Resources.Pids.Limit.
-func (g *Generator) SetLinuxResourcesPidsLimit(limit int64) {
+// Use PidsUnlimited for no limit.  A limit of 0 is rejected, since
+// runtimes disagree on whether it means no limit or no processes at all.
+func (g *Generator) SetLinuxResourcesPidsLimit(limit int64) error {
+	if limit == 0 || limit < PidsUnlimited {
+		return fmt.Errorf("invalid pids limit %d, must be positive or %d for unlimited", limit, PidsUnlimited)
+	}
 	g.initConfigLinuxResourcesPids()
 	g.Config.Linux.Resources.Pids.Limit = limit
+	return nil
 }
 
 // ClearLinuxSysctl clears g.Config.Linux.Sysctl.
@@ -948,6 +1387,17 @@ func (g *Generator) AddLinuxSysctl(key, value string) {
 	g.Config.Linux.Sysctl[key] = value
 }
 
+// AddLinuxNamespacedSysctl adds a new sysctl config into g.Config.Linux.Sysctl,
+// like AddLinuxSysctl, but rejects sysctls which are not isolated by a
+// namespace and would therefore affect the host.
+func (g *Generator) AddLinuxNamespacedSysctl(key, value string) error {
+	if _, namespaced := validate.SysctlNamespace(key); !namespaced {
+		return fmt.Errorf("sysctl %s is not namespaced", key)
+	}
+	g.AddLinuxSysctl(key, value)
+	return nil
+}
+
 // RemoveLinuxSysctl removes a sysctl config from g.Config.Linux.Sysctl.
 func (g *Generator) RemoveLinuxSysctl(key string) {
 	if g.Config == nil || g.Config.Linux == nil || g.Config.Linux.Sysctl == nil {
@@ -956,6 +1406,21 @@ func (g *Generator) RemoveLinuxSysctl(key string) {
 	delete(g.Config.Linux.Sysctl, key)
 }
 
+// maxIDMappings is the number of lines the kernel accepts in uid_map and
+// gid_map (before Linux 4.15).
+const maxIDMappings = 5
+
+// SetLinuxUIDMappings sets g.Config.Linux.UIDMappings, replacing any
+// existing mappings.
+func (g *Generator) SetLinuxUIDMappings(mappings []rspec.LinuxIDMapping) error {
+	if len(mappings) > maxIDMappings {
+		return fmt.Errorf("too many uid mappings: %d, the maximum is %d", len(mappings), maxIDMappings)
+	}
+	g.initConfigLinux()
+	g.Config.Linux.UIDMappings = append([]rspec.LinuxIDMapping{}, mappings...)
+	return nil
+}
+
 // ClearLinuxUIDMappings clear g.Config.Linux.UIDMappings.
 func (g *Generator) ClearLinuxUIDMappings() {
 	if g.Config == nil || g.Config.Linux == nil {
@@ -976,6 +1441,17 @@ func (g *Generator) AddLinuxUIDMapping(hid, cid, size uint32) {
 	g.Config.Linux.UIDMappings = append(g.Config.Linux.UIDMappings, idMapping)
 }
 
+// SetLinuxGIDMappings sets g.Config.Linux.GIDMappings, replacing any
+// existing mappings.
+func (g *Generator) SetLinuxGIDMappings(mappings []rspec.LinuxIDMapping) error {
+	if len(mappings) > maxIDMappings {
+		return fmt.Errorf("too many gid mappings: %d, the maximum is %d", len(mappings), maxIDMappings)
+	}
+	g.initConfigLinux()
+	g.Config.Linux.GIDMappings = append([]rspec.LinuxIDMapping{}, mappings...)
+	return nil
+}
+
 // ClearLinuxGIDMappings clear g.Config.Linux.GIDMappings.
 func (g *Generator) ClearLinuxGIDMappings() {
 	if g.Config == nil || g.Config.Linux == nil {
@@ -1016,6 +1492,14 @@ func (g *Generator) SetLinuxRootPropagation(rp string) error {
 	return nil
 }
 
+// GetLinuxRootPropagation returns g.Config.Linux.RootfsPropagation.
+func (g *Generator) GetLinuxRootPropagation() string {
+	if g.Config == nil || g.Config.Linux == nil {
+		return ""
+	}
+	return g.Config.Linux.RootfsPropagation
+}
+
 // ClearPreStartHooks clear g.Config.Hooks.Prestart.
 func (g *Generator) ClearPreStartHooks() {
 	if g.Config == nil || g.Config.Hooks == nil {
@@ -1061,13 +1545,305 @@ func (g *Generator) AddPostStartHook(postStartHook rspec.Hook) error {
 	return nil
 }
 
-// AddMount adds a mount into g.Config.Mounts.
+// SetHooks replaces g.Config.Hooks with a copy of hooks, or removes the
+// hooks if hooks is nil.  Every hook must have a path.
+func (g *Generator) SetHooks(hooks *rspec.Hooks) error {
+	if hooks == nil {
+		g.ClearHooks()
+		return nil
+	}
+
+	for _, h := range []struct {
+		name  string
+		hooks []rspec.Hook
+	}{
+		{"prestart", hooks.Prestart},
+		{"createRuntime", hooks.CreateRuntime},
+		{"createContainer", hooks.CreateContainer},
+		{"startContainer", hooks.StartContainer},
+		{"poststart", hooks.Poststart},
+		{"poststop", hooks.Poststop},
+	} {
+		for i, hook := range h.hooks {
+			if hook.Path == "" {
+				return fmt.Errorf("%s hook %d has no path", h.name, i)
+			}
+		}
+	}
+
+	g.initConfig()
+	g.Config.Hooks = &rspec.Hooks{
+		Prestart:        copyHooks(hooks.Prestart),
+		CreateRuntime:   copyHooks(hooks.CreateRuntime),
+		CreateContainer: copyHooks(hooks.CreateContainer),
+		StartContainer:  copyHooks(hooks.StartContainer),
+		Poststart:       copyHooks(hooks.Poststart),
+		Poststop:        copyHooks(hooks.Poststop),
+	}
+	return nil
+}
+
+// ClearHooks removes g.Config.Hooks.
+func (g *Generator) ClearHooks() {
+	if g.Config == nil {
+		return
+	}
+	g.Config.Hooks = nil
+}
+
+// AddMount adds a mount into g.Config.Mounts.  The options are copied as
+// they are, so options only some runtimes or filesystems understand, like
+// cache=mmap, are kept.
 func (g *Generator) AddMount(mnt rspec.Mount) {
 	g.initConfig()
 
+	mnt.Options = copyStrings(mnt.Options)
 	g.Config.Mounts = append(g.Config.Mounts, mnt)
 }
 
+// isBindMount returns true if mnt is a bind mount.
+func isBindMount(mnt rspec.Mount) bool {
+	if mnt.Type == "bind" {
+		return true
+	}
+	for _, option := range mnt.Options {
+		if option == "bind" || option == "rbind" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBindMount returns an error if the bind mount of source on dest has
+// no destination, or, if g.HostSpecific is set, a relative source.
+func (g *Generator) checkBindMount(source, dest string) error {
+	if dest == "" {
+		return fmt.Errorf("bind mount of %s has no destination", source)
+	}
+	if g.HostSpecific && !filepath.IsAbs(source) {
+		return fmt.Errorf("source %q of the bind mount on %s is not an absolute path", source, dest)
+	}
+	return nil
+}
+
+// AddBindMount adds a bind mount of the host path source on dest into
+// g.Config.Mounts.  The options are kept as they are, like with AddMount,
+// so the mount is only recursive if they include rbind.  If g.HostSpecific
+// is set, source must be an absolute path; otherwise a relative source is
+// kept, and Validate warns about it.
+func (g *Generator) AddBindMount(source, dest string, options []string) error {
+	if err := g.checkBindMount(source, dest); err != nil {
+		return err
+	}
+
+	g.AddMount(rspec.Mount{
+		Destination: dest,
+		Type:        "bind",
+		Source:      source,
+		Options:     options,
+	})
+	return nil
+}
+
+var (
+	// tmpfsDataOptions are the tmpfs specific options, which take a value
+	tmpfsDataOptions = map[string]bool{
+		"gid":       true,
+		"huge":      true,
+		"mode":      true,
+		"mpol":      true,
+		"nr_blocks": true,
+		"nr_inodes": true,
+		"size":      true,
+		"uid":       true,
+	}
+
+	// mountFlagOptions are the generic mount flags accepted by any mount
+	mountFlagOptions = map[string]bool{
+		"async":         true,
+		"atime":         true,
+		"dev":           true,
+		"diratime":      true,
+		"dirsync":       true,
+		"exec":          true,
+		"mand":          true,
+		"noatime":       true,
+		"nodev":         true,
+		"nodiratime":    true,
+		"noexec":        true,
+		"nomand":        true,
+		"norelatime":    true,
+		"nostrictatime": true,
+		"nosuid":        true,
+		"private":       true,
+		"rprivate":      true,
+		"relatime":      true,
+		"ro":            true,
+		"rshared":       true,
+		"rslave":        true,
+		"runbindable":   true,
+		"rw":            true,
+		"shared":        true,
+		"slave":         true,
+		"strictatime":   true,
+		"suid":          true,
+		"sync":          true,
+		"unbindable":    true,
+	}
+)
+
+// TmpfsDefaultMode is the mode of a tmpfs mount on one of the temporary
+// directories when no mode option is given.
+const TmpfsDefaultMode = "1777"
+
+// tmpDirs are the temporary directories, which are world-writable with the
+// sticky bit set.
+var tmpDirs = map[string]bool{
+	"/dev/shm": true,
+	"/tmp":     true,
+	"/var/tmp": true,
+}
+
+// TmpfsOptions are the options of a tmpfs mount. Unset fields are left to
+// the kernel defaults.
+type TmpfsOptions struct {
+	// Mode is the permission bits of the root directory, e.g. 01777.
+	Mode *uint32
+	// Size is the size limit, e.g. "64m" or "50%".
+	Size string
+	// UID and GID are the owner of the root directory.
+	UID *uint32
+	GID *uint32
+	// Flags are generic mount flags like "nosuid" or "ro".
+	Flags []string
+}
+
+// Options returns the tmpfs options in the form of mount options.
+func (o TmpfsOptions) Options() []string {
+	options := append([]string{}, o.Flags...)
+	if o.Mode != nil {
+		options = append(options, fmt.Sprintf("mode=%o", *o.Mode))
+	}
+	if o.Size != "" {
+		options = append(options, "size="+o.Size)
+	}
+	if o.UID != nil {
+		options = append(options, fmt.Sprintf("uid=%d", *o.UID))
+	}
+	if o.GID != nil {
+		options = append(options, fmt.Sprintf("gid=%d", *o.GID))
+	}
+	return options
+}
+
+// AddTmpfsMount adds a tmpfs mount on dest into g.Config.Mounts. It returns
+// an error if one of the options is not a known tmpfs option or mount flag,
+// or if the mode is not octal. A mount on /tmp, /var/tmp or /dev/shm
+// without a mode option gets TmpfsDefaultMode.
+func (g *Generator) AddTmpfsMount(dest string, options []string) error {
+	hasMode := false
+	for _, option := range options {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) == 2 {
+			if !tmpfsDataOptions[kv[0]] {
+				return fmt.Errorf("unknown tmpfs option %q", option)
+			}
+			if kv[1] == "" {
+				return fmt.Errorf("tmpfs option %q requires a value", kv[0])
+			}
+			if kv[0] == "mode" {
+				if mode, err := strconv.ParseUint(kv[1], 8, 32); err != nil || mode > 07777 {
+					return fmt.Errorf("tmpfs mode %q must be an octal number up to 7777", kv[1])
+				}
+				hasMode = true
+			}
+		} else if !mountFlagOptions[option] {
+			return fmt.Errorf("unknown tmpfs option %q", option)
+		}
+	}
+	if !hasMode && tmpDirs[filepath.Clean(dest)] {
+		options = append(append([]string{}, options...), "mode="+TmpfsDefaultMode)
+	}
+
+	g.AddMount(rspec.Mount{
+		Destination: dest,
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     options,
+	})
+	return nil
+}
+
+// AddTmpfsMountWithOptions adds a tmpfs mount on dest with the structured
+// options into g.Config.Mounts, see AddTmpfsMount.
+func (g *Generator) AddTmpfsMountWithOptions(dest string, options TmpfsOptions) error {
+	return g.AddTmpfsMount(dest, options.Options())
+}
+
+// AddCgroupsMount adds a cgroup mount on /sys/fs/cgroup into g.Config.Mounts,
+// replacing any existing mount there.  mode is one of "rw" and "ro", which
+// mount the cgroup filesystem read-write or read-only, or "no", which only
+// removes the existing mount, leaving no mount at all.
+func (g *Generator) AddCgroupsMount(mode string) error {
+	switch mode {
+	case "ro":
+	case "rw":
+	case "no":
+	default:
+		return fmt.Errorf("cgroups mount mode %q must be one of (ro,rw,no)", mode)
+	}
+
+	g.RemoveMount("/sys/fs/cgroup")
+	if mode == "no" {
+		return nil
+	}
+	g.AddMount(rspec.Mount{
+		Destination: "/sys/fs/cgroup",
+		Type:        "cgroup",
+		Source:      "cgroup",
+		Options:     []string{"nosuid", "noexec", "nodev", "relatime", mode},
+	})
+	return nil
+}
+
+// AddMountsFromFile reads a JSON array of mounts from path and adds them
+// into g.Config.Mounts. A mount replaces any existing mount on the same
+// destination.
+func (g *Generator) AddMountsFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var mounts []rspec.Mount
+	if err := json.NewDecoder(f).Decode(&mounts); err != nil {
+		return fmt.Errorf("invalid mounts file %s: %v", path, err)
+	}
+	for i, mnt := range mounts {
+		if mnt.Destination == "" {
+			return fmt.Errorf("mount %d in %s has no destination", i, path)
+		}
+		if mnt.Type == "bind" {
+			if err := g.checkBindMount(mnt.Source, mnt.Destination); err != nil {
+				return fmt.Errorf("mount %d in %s: %v", i, path, err)
+			}
+		}
+	}
+
+	for _, mnt := range mounts {
+		g.RemoveMount(mnt.Destination)
+		if mnt.Type == "bind" {
+			if err := g.AddBindMount(mnt.Source, mnt.Destination, mnt.Options); err != nil {
+				return err
+			}
+			continue
+		}
+		g.AddMount(mnt)
+	}
+	return nil
+}
+
 // RemoveMount removes a mount point on the dest directory
 func (g *Generator) RemoveMount(dest string) {
 	g.initConfig()
@@ -1103,7 +1879,7 @@ func (g *Generator) SetupPrivileged(privileged bool) {
 			if g.HostSpecific && cap > validate.LastCap() {
 				continue
 			}
-			finalCapList = append(finalCapList, fmt.Sprintf("CAP_%s", strings.ToUpper(cap.String())))
+			finalCapList = append(finalCapList, validate.CapabilityString(cap))
 		}
 		g.initConfigLinux()
 		g.initConfigProcessCapabilities()
@@ -1119,6 +1895,35 @@ func (g *Generator) SetupPrivileged(privileged bool) {
 	}
 }
 
+// AddAllProcessCapabilities adds every capability up to the last
+// capability of the host kernel, validate.LastCap, to all 5 capability
+// sets.  Capabilities newer than the capability package are left out.  Unlike
+// SetupPrivileged, it keeps the capabilities already configured and does
+// not change the other security settings.
+func (g *Generator) AddAllProcessCapabilities() error {
+	for _, cap := range validate.AllCapabilityStrings() {
+		if err := g.AddProcessCapability(cap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProcessCapabilities returns a copy of g.Config.Process.Capabilities.
+func (g *Generator) GetProcessCapabilities() *rspec.LinuxCapabilities {
+	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
+		return nil
+	}
+	caps := g.Config.Process.Capabilities
+	return &rspec.LinuxCapabilities{
+		Bounding:    copyStrings(caps.Bounding),
+		Effective:   copyStrings(caps.Effective),
+		Inheritable: copyStrings(caps.Inheritable),
+		Permitted:   copyStrings(caps.Permitted),
+		Ambient:     copyStrings(caps.Ambient),
+	}
+}
+
 // ClearProcessCapabilities clear g.Config.Process.Capabilities.
 func (g *Generator) ClearProcessCapabilities() {
 	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
@@ -1131,6 +1936,13 @@ func (g *Generator) ClearProcessCapabilities() {
 	g.Config.Process.Capabilities.Ambient = []string{}
 }
 
+// DropAllProcessCapabilities empties all 5 capability sets of
+// g.Config.Process.Capabilities, like SetCapabilitiesProfile("none").
+func (g *Generator) DropAllProcessCapabilities() {
+	g.initConfigProcessCapabilities()
+	g.ClearProcessCapabilities()
+}
+
 // AddProcessCapability adds a process capability into all 5 capability sets.
 func (g *Generator) AddProcessCapability(c string) error {
 	cp := strings.ToUpper(c)
@@ -1310,120 +2122,123 @@ func (g *Generator) AddProcessCapabilityPermitted(c string) error {
 	return nil
 }
 
-// DropProcessCapability drops a process capability from all 5 capability sets.
-func (g *Generator) DropProcessCapability(c string) error {
-	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
-		return nil
+// dropCapability returns caps without any entry matching the upper-case
+// capability name cp, keeping the order and only the first of duplicated
+// entries.
+func dropCapability(caps []string, cp string) []string {
+	seen := map[string]bool{cp: true}
+	kept := make([]string, 0, len(caps))
+	for _, cap := range caps {
+		name := strings.ToUpper(cap)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		kept = append(kept, cap)
 	}
+	return kept
+}
 
+// DropProcessCapability drops a process capability from all 5 capability
+// sets.  Like the DropProcessCapability<Set> methods, which only change one
+// set, it also removes duplicated entries from the sets it changes.
+func (g *Generator) DropProcessCapability(c string) error {
 	cp := strings.ToUpper(c)
-	for i, cap := range g.Config.Process.Capabilities.Ambient {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Ambient = removeFunc(g.Config.Process.Capabilities.Ambient, i)
-		}
-	}
-	for i, cap := range g.Config.Process.Capabilities.Bounding {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Bounding = removeFunc(g.Config.Process.Capabilities.Bounding, i)
-		}
-	}
-	for i, cap := range g.Config.Process.Capabilities.Effective {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Effective = removeFunc(g.Config.Process.Capabilities.Effective, i)
-		}
-	}
-	for i, cap := range g.Config.Process.Capabilities.Inheritable {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Inheritable = removeFunc(g.Config.Process.Capabilities.Inheritable, i)
-		}
+	if err := validate.CapValid(cp, false); err != nil {
+		return err
 	}
-	for i, cap := range g.Config.Process.Capabilities.Permitted {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Permitted = removeFunc(g.Config.Process.Capabilities.Permitted, i)
-		}
+
+	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
+		return nil
 	}
 
-	return validate.CapValid(cp, false)
+	g.Config.Process.Capabilities.Ambient = dropCapability(g.Config.Process.Capabilities.Ambient, cp)
+	g.Config.Process.Capabilities.Bounding = dropCapability(g.Config.Process.Capabilities.Bounding, cp)
+	g.Config.Process.Capabilities.Effective = dropCapability(g.Config.Process.Capabilities.Effective, cp)
+	g.Config.Process.Capabilities.Inheritable = dropCapability(g.Config.Process.Capabilities.Inheritable, cp)
+	g.Config.Process.Capabilities.Permitted = dropCapability(g.Config.Process.Capabilities.Permitted, cp)
+
+	return nil
 }
 
 // DropProcessCapabilityAmbient drops a process capability from g.Config.Process.Capabilities.Ambient.
 func (g *Generator) DropProcessCapabilityAmbient(c string) error {
+	cp := strings.ToUpper(c)
+	if err := validate.CapValid(cp, false); err != nil {
+		return err
+	}
+
 	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
 		return nil
 	}
 
-	cp := strings.ToUpper(c)
-	for i, cap := range g.Config.Process.Capabilities.Ambient {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Ambient = removeFunc(g.Config.Process.Capabilities.Ambient, i)
-		}
-	}
+	g.Config.Process.Capabilities.Ambient = dropCapability(g.Config.Process.Capabilities.Ambient, cp)
 
-	return validate.CapValid(cp, false)
+	return nil
 }
 
 // DropProcessCapabilityBounding drops a process capability from g.Config.Process.Capabilities.Bounding.
 func (g *Generator) DropProcessCapabilityBounding(c string) error {
+	cp := strings.ToUpper(c)
+	if err := validate.CapValid(cp, false); err != nil {
+		return err
+	}
+
 	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
 		return nil
 	}
 
-	cp := strings.ToUpper(c)
-	for i, cap := range g.Config.Process.Capabilities.Bounding {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Bounding = removeFunc(g.Config.Process.Capabilities.Bounding, i)
-		}
-	}
+	g.Config.Process.Capabilities.Bounding = dropCapability(g.Config.Process.Capabilities.Bounding, cp)
 
-	return validate.CapValid(cp, false)
+	return nil
 }
 
 // DropProcessCapabilityEffective drops a process capability from g.Config.Process.Capabilities.Effective.
 func (g *Generator) DropProcessCapabilityEffective(c string) error {
+	cp := strings.ToUpper(c)
+	if err := validate.CapValid(cp, false); err != nil {
+		return err
+	}
+
 	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
 		return nil
 	}
 
-	cp := strings.ToUpper(c)
-	for i, cap := range g.Config.Process.Capabilities.Effective {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Effective = removeFunc(g.Config.Process.Capabilities.Effective, i)
-		}
-	}
+	g.Config.Process.Capabilities.Effective = dropCapability(g.Config.Process.Capabilities.Effective, cp)
 
-	return validate.CapValid(cp, false)
+	return nil
 }
 
 // DropProcessCapabilityInheritable drops a process capability from g.Config.Process.Capabilities.Inheritable.
 func (g *Generator) DropProcessCapabilityInheritable(c string) error {
+	cp := strings.ToUpper(c)
+	if err := validate.CapValid(cp, false); err != nil {
+		return err
+	}
+
 	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
 		return nil
 	}
 
-	cp := strings.ToUpper(c)
-	for i, cap := range g.Config.Process.Capabilities.Inheritable {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Inheritable = removeFunc(g.Config.Process.Capabilities.Inheritable, i)
-		}
-	}
+	g.Config.Process.Capabilities.Inheritable = dropCapability(g.Config.Process.Capabilities.Inheritable, cp)
 
-	return validate.CapValid(cp, false)
+	return nil
 }
 
 // DropProcessCapabilityPermitted drops a process capability from g.Config.Process.Capabilities.Permitted.
 func (g *Generator) DropProcessCapabilityPermitted(c string) error {
+	cp := strings.ToUpper(c)
+	if err := validate.CapValid(cp, false); err != nil {
+		return err
+	}
+
 	if g.Config == nil || g.Config.Process == nil || g.Config.Process.Capabilities == nil {
 		return nil
 	}
 
-	cp := strings.ToUpper(c)
-	for i, cap := range g.Config.Process.Capabilities.Permitted {
-		if strings.ToUpper(cap) == cp {
-			g.Config.Process.Capabilities.Permitted = removeFunc(g.Config.Process.Capabilities.Permitted, i)
-		}
-	}
+	g.Config.Process.Capabilities.Permitted = dropCapability(g.Config.Process.Capabilities.Permitted, cp)
 
-	return validate.CapValid(cp, false)
+	return nil
 }
 
 func mapStrToNamespace(ns string, path string) (rspec.LinuxNamespace, error) {
@@ -1456,12 +2271,21 @@ func (g *Generator) ClearLinuxNamespaces() {
 }
 
 // AddOrReplaceLinuxNamespace adds or replaces a namespace inside
-// g.Config.Linux.Namespaces.
+// g.Config.Linux.Namespaces.  If g.HostSpecific is set, a non-empty path
+// must be an existing namespace file, like /proc/<pid>/ns/<type>.
 func (g *Generator) AddOrReplaceLinuxNamespace(ns string, path string) error {
 	namespace, err := mapStrToNamespace(ns, path)
 	if err != nil {
 		return err
 	}
+	if g.HostSpecific && path != "" {
+		if _, err := os.Lstat(path); err != nil {
+			return fmt.Errorf("cannot find the %s namespace path %q: %v", ns, path, err)
+		}
+		if !isNamespaceFile(path) {
+			return fmt.Errorf("%s namespace path %q is not a namespace file", ns, path)
+		}
+	}
 
 	g.initConfigLinux()
 	for i, ns := range g.Config.Linux.Namespaces {
@@ -1493,18 +2317,75 @@ func (g *Generator) RemoveLinuxNamespace(ns string) error {
 	return nil
 }
 
-// AddDevice - add a device into g.Config.Linux.Devices
-func (g *Generator) AddDevice(device rspec.LinuxDevice) {
+// GetLinuxNamespaces returns a copy of g.Config.Linux.Namespaces.
+func (g *Generator) GetLinuxNamespaces() []rspec.LinuxNamespace {
+	if g.Config == nil || g.Config.Linux == nil || g.Config.Linux.Namespaces == nil {
+		return nil
+	}
+	return append([]rspec.LinuxNamespace{}, g.Config.Linux.Namespaces...)
+}
+
+// HasLinuxNamespace returns true if g.Config.Linux.Namespaces contains
+// the namespace ns, given by the same names AddOrReplaceLinuxNamespace
+// accepts.
+func (g *Generator) HasLinuxNamespace(ns string) bool {
+	namespace, err := mapStrToNamespace(ns, "")
+	if err != nil || g.Config == nil || g.Config.Linux == nil {
+		return false
+	}
+	for _, ns := range g.Config.Linux.Namespaces {
+		if ns.Type == namespace.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceTypes are the valid linux.devices types.
+var deviceTypes = map[string]bool{
+	"b": true, // a block (buffered) special file
+	"c": true, // a character special file
+	"u": true, // a character (unbuffered) special file
+	"p": true, // a FIFO
+}
+
+// deviceValid checks the type and the device numbers of device.
+func deviceValid(device rspec.LinuxDevice) error {
+	if !deviceTypes[device.Type] {
+		return fmt.Errorf("invalid type %q of device %s, must be one of b, c, u and p", device.Type, device.Path)
+	}
+	if device.Major < 0 || device.Minor < 0 {
+		return fmt.Errorf("device %s has a negative number %d:%d", device.Path, device.Major, device.Minor)
+	}
+	// a FIFO has no device numbers, they are ignored
+	if device.Type != "p" && device.Major == 0 {
+		return fmt.Errorf("device %s of type %s requires a major number", device.Path, device.Type)
+	}
+	return nil
+}
+
+// AddDevice - add a device into g.Config.Linux.Devices. A device replaces
+// any existing device on the same path. It returns an error if the type
+// or the device numbers are invalid.
+func (g *Generator) AddDevice(device rspec.LinuxDevice) error {
+	if !filepath.IsAbs(device.Path) {
+		return fmt.Errorf("device path %q must be absolute", device.Path)
+	}
+	if err := deviceValid(device); err != nil {
+		return err
+	}
+
 	g.initConfigLinux()
 
 	for i, dev := range g.Config.Linux.Devices {
 		if dev.Path == device.Path {
 			g.Config.Linux.Devices[i] = device
-			return
+			return nil
 		}
 	}
 
 	g.Config.Linux.Devices = append(g.Config.Linux.Devices, device)
+	return nil
 }
 
 // RemoveDevice remove a device from g.Config.Linux.Devices
@@ -1591,6 +2472,14 @@ func (g *Generator) SetSeccompArchitecture(architecture string) error {
 	return seccomp.ParseArchitectureFlag(architecture, g.Config.Linux.Seccomp)
 }
 
+// RemoveSeccompArchitecture removes a seccomp architecture
+func (g *Generator) RemoveSeccompArchitecture(architecture string) error {
+	if g.Config == nil || g.Config.Linux == nil || g.Config.Linux.Seccomp == nil {
+		return nil
+	}
+	return seccomp.RemoveArchitecture(architecture, g.Config.Linux.Seccomp)
+}
+
 // RemoveSeccompRule removes rules for any specified syscalls
 func (g *Generator) RemoveSeccompRule(arguments string) error {
 	g.initConfigLinuxSeccomp()
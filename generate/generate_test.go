@@ -1,14 +1,20 @@
 package generate_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
 	rfc2119 "github.com/opencontainers/runtime-tools/error"
 	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/generate/seccomp"
 	"github.com/opencontainers/runtime-tools/specerror"
 	"github.com/opencontainers/runtime-tools/validate"
 	"github.com/stretchr/testify/assert"
@@ -125,6 +131,12 @@ func TestEnvCaching(t *testing.T) {
 	}
 	g.AddProcessEnv("", "")
 	assert.Equal(t, []string(nil), g.Config.Process.Env)
+
+	// Test invalid names and empty values
+	assert.NotNil(t, g.AddProcessEnv("1k", "v1"))
+	assert.NotNil(t, g.AddProcessEnv("k-1", "v1"))
+	assert.Nil(t, g.AddProcessEnv("_k1", ""))
+	assert.Equal(t, []string{"_k1="}, g.Config.Process.Env)
 }
 
 func TestMultipleEnvCaching(t *testing.T) {
@@ -155,4 +167,1386 @@ func TestMultipleEnvCaching(t *testing.T) {
 	}
 	g.AddMultipleProcessEnv([]string{})
 	assert.Equal(t, []string(nil), g.Config.Process.Env)
+
+	// Test malformed ENV
+	assert.NotNil(t, g.AddMultipleProcessEnv([]string{"k1=v1", "k2"}))
+	assert.Equal(t, []string(nil), g.Config.Process.Env)
+}
+
+func TestAdditionalGids(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.AddProcessAdditionalGid(10)
+	g.AddProcessAdditionalGid(5)
+	g.AddProcessAdditionalGid(10)
+	g.AddProcessAdditionalGid(7)
+	assert.Equal(t, []uint32{5, 7, 10}, g.Config.Process.User.AdditionalGids)
+
+	g.RemoveProcessAdditionalGid(7)
+	assert.Equal(t, []uint32{5, 10}, g.Config.Process.User.AdditionalGids)
+}
+
+func TestProcessRoundTrip(t *testing.T) {
+	g := generate.Generator{}
+	g.SetProcessCwd("/tmp")
+	g.SetProcessArgs([]string{"sh", "-c", "true"})
+	g.AddProcessEnv("k1", "v1")
+	g.AddProcessCapability("CAP_CHOWN")
+	g.SetProcessOOMScoreAdj(100)
+	g.AddProcessRlimits("RLIMIT_NOFILE", 1024, 1024)
+
+	process := g.Process()
+	data, err := json.Marshal(process)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded rspec.Process
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, process, &decoded)
+
+	// the returned process must not share state with the generator
+	process.Args[0] = "bash"
+	*process.OOMScoreAdj = 0
+	assert.Equal(t, "sh", g.Config.Process.Args[0])
+	assert.Equal(t, 100, *g.Config.Process.OOMScoreAdj)
+}
+
+func TestAddTmpfsMount(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := len(g.Mounts())
+
+	err = g.AddTmpfsMount("/run", []string{"nosuid", "mode=755", "size=65536k"})
+	assert.Nil(t, err)
+	assert.Equal(t, size+1, len(g.Mounts()))
+
+	err = g.AddTmpfsMount("/tmp", []string{"siez=10m"})
+	assert.NotNil(t, err)
+	assert.Equal(t, size+1, len(g.Mounts()))
+
+	for _, mode := range []string{"mode=abc", "mode=999", "mode=17777"} {
+		assert.NotNil(t, g.AddTmpfsMount("/tmp", []string{mode}), mode)
+	}
+	assert.Equal(t, size+1, len(g.Mounts()))
+
+	assert.Nil(t, g.AddTmpfsMount("/tmp", []string{"nosuid"}))
+	mounts := g.Mounts()
+	assert.Equal(t, []string{"nosuid", "mode=1777"}, mounts[len(mounts)-1].Options)
+
+	assert.Nil(t, g.AddTmpfsMount("/var/tmp", []string{"mode=700"}))
+	mounts = g.Mounts()
+	assert.Equal(t, []string{"mode=700"}, mounts[len(mounts)-1].Options)
+
+	mode, uid := uint32(01777), uint32(1000)
+	assert.Nil(t, g.AddTmpfsMountWithOptions("/scratch", generate.TmpfsOptions{
+		Mode:  &mode,
+		Size:  "64m",
+		UID:   &uid,
+		Flags: []string{"nosuid", "nodev"},
+	}))
+	mounts = g.Mounts()
+	assert.Equal(t, rspec.Mount{
+		Destination: "/scratch",
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     []string{"nosuid", "nodev", "mode=1777", "size=64m", "uid=1000"},
+	}, mounts[len(mounts)-1])
+}
+
+func TestAddCgroupsMount(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := len(g.Mounts())
+
+	assert.Nil(t, g.AddCgroupsMount("no"))
+	assert.Equal(t, size, len(g.Mounts()))
+
+	assert.NotNil(t, g.AddCgroupsMount("readonly"))
+	assert.Equal(t, size, len(g.Mounts()))
+
+	// each mount replaces the previous one
+	for _, mode := range []string{"ro", "rw", "rw"} {
+		assert.Nil(t, g.AddCgroupsMount(mode))
+		mounts := g.Mounts()
+		assert.Equal(t, size+1, len(mounts))
+		assert.Equal(t, rspec.Mount{
+			Destination: "/sys/fs/cgroup",
+			Type:        "cgroup",
+			Source:      "cgroup",
+			Options:     []string{"nosuid", "noexec", "nodev", "relatime", mode},
+		}, mounts[len(mounts)-1])
+	}
+
+	// "no" removes the mount
+	assert.Nil(t, g.AddCgroupsMount("no"))
+	assert.Equal(t, size, len(g.Mounts()))
+	for _, mount := range g.Mounts() {
+		assert.NotEqual(t, "/sys/fs/cgroup", mount.Destination)
+	}
+}
+
+func TestSetProcessAndLinux(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, g.SetProcess(&rspec.Process{Cwd: "/"}))
+
+	process := &rspec.Process{
+		Args: []string{"sh"},
+		Env:  []string{"k1=v1"},
+		Cwd:  "/",
+	}
+	assert.Nil(t, g.SetProcess(process))
+	process.Args[0] = "bash"
+	process.Env[0] = "k1=v2"
+	assert.Equal(t, []string{"sh"}, g.Config.Process.Args)
+	assert.Equal(t, []string{"k1=v1"}, g.Config.Process.Env)
+
+	// the env cache follows the new process
+	g.AddProcessEnv("k1", "v3")
+	assert.Equal(t, []string{"k1=v3"}, g.Config.Process.Env)
+
+	limit := int64(10)
+	linux := &rspec.Linux{
+		Sysctl:    map[string]string{"net.ipv4.ip_forward": "1"},
+		Resources: &rspec.LinuxResources{Pids: &rspec.LinuxPids{Limit: limit}},
+	}
+	assert.Nil(t, g.SetLinux(linux))
+	linux.Sysctl["net.ipv4.ip_forward"] = "0"
+	linux.Resources.Pids.Limit = 20
+	assert.Equal(t, "1", g.Config.Linux.Sysctl["net.ipv4.ip_forward"])
+	assert.Equal(t, limit, g.Config.Linux.Resources.Pids.Limit)
+}
+
+func TestSetCapabilitiesProfile(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := g.Config.Process.Capabilities.Bounding
+
+	assert.NotNil(t, g.SetCapabilitiesProfile("unknown"))
+	assert.Equal(t, defaults, g.Config.Process.Capabilities.Bounding)
+
+	assert.Nil(t, g.SetCapabilitiesProfile(generate.CapabilitiesProfileNone))
+	caps := g.Config.Process.Capabilities
+	for _, set := range [][]string{caps.Bounding, caps.Effective, caps.Inheritable, caps.Permitted, caps.Ambient} {
+		assert.Empty(t, set)
+	}
+
+	assert.Nil(t, g.AddProcessCapability("CAP_CHOWN"))
+	assert.Equal(t, []string{"CAP_CHOWN"}, caps.Bounding)
+
+	assert.Nil(t, g.SetCapabilitiesProfile(generate.CapabilitiesProfileDefault))
+	caps = g.Config.Process.Capabilities
+	for _, set := range [][]string{caps.Bounding, caps.Effective, caps.Permitted} {
+		assert.Equal(t, defaults, set)
+	}
+	assert.Empty(t, caps.Inheritable)
+	assert.Empty(t, caps.Ambient)
+
+	assert.Nil(t, g.DropProcessCapabilityBounding("CAP_KILL"))
+	assert.Equal(t, len(defaults), len(caps.Effective))
+	assert.Equal(t, len(defaults)-1, len(caps.Bounding))
+}
+
+func TestProcessCapabilityNames(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []func(string) error{
+		g.AddProcessCapability,
+		g.AddProcessCapabilityAmbient,
+		g.AddProcessCapabilityBounding,
+		g.AddProcessCapabilityEffective,
+		g.AddProcessCapabilityInheritable,
+		g.AddProcessCapabilityPermitted,
+		g.DropProcessCapability,
+		g.DropProcessCapabilityAmbient,
+		g.DropProcessCapabilityBounding,
+		g.DropProcessCapabilityEffective,
+		g.DropProcessCapabilityInheritable,
+		g.DropProcessCapabilityPermitted,
+	} {
+		err := f("CAP_BOGUS")
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "CAP_BOGUS")
+		}
+		assert.NotNil(t, f("CHOWN"))
+		assert.Nil(t, f("cap_chown"))
+	}
+	assert.NotContains(t, g.Config.Process.Capabilities.Bounding, "CAP_BOGUS")
+}
+
+func TestGetProcessCapabilities(t *testing.T) {
+	template := `{"ociVersion": "1.0.0", "process": {"cwd": "/", "capabilities": {"bounding": ["CAP_CHOWN", "CAP_KILL"], "effective": ["CAP_KILL"], "permitted": ["CAP_KILL"]}}}`
+	g, err := generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caps := g.GetProcessCapabilities()
+	assert.Equal(t, &rspec.LinuxCapabilities{
+		Bounding:  []string{"CAP_CHOWN", "CAP_KILL"},
+		Effective: []string{"CAP_KILL"},
+		Permitted: []string{"CAP_KILL"},
+	}, caps)
+
+	// the returned capabilities are a copy
+	caps.Bounding[0] = "CAP_SYS_ADMIN"
+	assert.Equal(t, "CAP_CHOWN", g.GetProcessCapabilities().Bounding[0])
+
+	assert.Nil(t, g.DropProcessCapabilityBounding("CAP_CHOWN"))
+	assert.Equal(t, []string{"CAP_KILL"}, g.GetProcessCapabilities().Bounding)
+
+	g, err = generate.NewFromTemplate(strings.NewReader(`{"ociVersion": "1.0.0"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, g.GetProcessCapabilities())
+}
+
+func TestDropAllProcessCapabilities(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.DropAllProcessCapabilities()
+	empty := &rspec.LinuxCapabilities{
+		Bounding:    []string{},
+		Effective:   []string{},
+		Inheritable: []string{},
+		Permitted:   []string{},
+		Ambient:     []string{},
+	}
+	assert.Equal(t, empty, g.Config.Process.Capabilities)
+
+	assert.Nil(t, g.AddProcessCapability("CAP_KILL"))
+	assert.Equal(t, []string{"CAP_KILL"}, g.Config.Process.Capabilities.Bounding)
+	assert.Equal(t, []string{"CAP_KILL"}, g.Config.Process.Capabilities.Ambient)
+
+	g.Config.Process = nil
+	g.DropAllProcessCapabilities()
+	assert.Equal(t, empty, g.Config.Process.Capabilities)
+}
+
+func TestAddMountsFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestAddMountsFromFile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := len(g.Mounts())
+
+	for _, tc := range []struct {
+		content string
+		valid   bool
+	}{
+		{`[{"destination": "/data", "type": "bind", "source": "/volumes/data", "options": ["rbind", "ro"]}]`, true},
+		{`[{"destination": "/proc", "type": "proc", "source": "proc", "options": ["nosuid"]}]`, true},
+		{`[{"type": "bind", "source": "/volumes/data"}]`, false},
+		{`{"destination": "/data"}`, false},
+	} {
+		path := filepath.Join(dir, "mounts.json")
+		if err := ioutil.WriteFile(path, []byte(tc.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := g.AddMountsFromFile(path)
+		if tc.valid {
+			assert.Nil(t, err, tc.content)
+		} else {
+			assert.NotNil(t, err, tc.content)
+		}
+	}
+
+	mounts := g.Mounts()
+	assert.Equal(t, size+1, len(mounts))
+	assert.Equal(t, rspec.Mount{Destination: "/proc", Type: "proc", Source: "proc", Options: []string{"nosuid"}}, mounts[len(mounts)-1])
+	assert.NotNil(t, g.AddMountsFromFile(filepath.Join(dir, "missing.json")))
+
+	// bind mounts need an absolute source on a specific host
+	g.HostSpecific = true
+	path := filepath.Join(dir, "mounts.json")
+	if err := ioutil.WriteFile(path, []byte(`[{"destination": "/cache", "type": "bind", "source": "volumes/cache"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, g.AddMountsFromFile(path))
+	assert.Equal(t, size+1, len(g.Mounts()))
+}
+
+func TestAddAnnotationsFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestAddAnnotationsFromFile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.AddAnnotation("com.example.key", "old")
+
+	for _, tc := range []struct {
+		content string
+		valid   bool
+	}{
+		{`{"com.example.key": "value", "config": "a=b=c"}`, true},
+		{`{"": "value"}`, false},
+		{`["com.example.key=value"]`, false},
+		{`{"com.example.key": 1}`, false},
+	} {
+		path := filepath.Join(dir, "annotations.json")
+		if err := ioutil.WriteFile(path, []byte(tc.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := g.AddAnnotationsFromFile(path)
+		if tc.valid {
+			assert.Nil(t, err, tc.content)
+		} else {
+			assert.NotNil(t, err, tc.content)
+		}
+	}
+
+	assert.Equal(t, map[string]string{"com.example.key": "value", "config": "a=b=c"}, g.Config.Annotations)
+	assert.NotNil(t, g.AddAnnotationsFromFile(filepath.Join(dir, "missing.json")))
+}
+
+func TestSetLinuxResourcesPidsLimit(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []int64{1000, generate.PidsUnlimited} {
+		assert.Nil(t, g.SetLinuxResourcesPidsLimit(limit))
+		assert.Equal(t, limit, g.Config.Linux.Resources.Pids.Limit)
+	}
+	for _, limit := range []int64{0, -2} {
+		assert.NotNil(t, g.SetLinuxResourcesPidsLimit(limit))
+		assert.Equal(t, generate.PidsUnlimited, g.Config.Linux.Resources.Pids.Limit)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Config.Process.Cwd = "/work/"
+	g.Config.Process.Capabilities.Bounding = []string{"CAP_KILL", "CAP_CHOWN", "CAP_KILL"}
+	g.Config.Process.User.AdditionalGids = []uint32{10, 5, 10}
+	g.Config.Process.Rlimits = append(g.Config.Process.Rlimits, rspec.POSIXRlimit{Type: "RLIMIT_NOFILE", Hard: 2048, Soft: 2048})
+	g.Config.Process.Env = []string{"B=1", "A=1"}
+	g.Config.Linux.MaskedPaths = []string{"/proc/kcore", "/proc//acpi", "/proc/kcore"}
+	g.Config.Linux.Resources = &rspec.LinuxResources{}
+	g.Config.Hooks = &rspec.Hooks{}
+
+	g.Normalize()
+	assert.Equal(t, "/work", g.Config.Process.Cwd)
+	assert.Equal(t, []string{"CAP_CHOWN", "CAP_KILL"}, g.Config.Process.Capabilities.Bounding)
+	assert.Equal(t, []uint32{5, 10}, g.Config.Process.User.AdditionalGids)
+	assert.Equal(t, []rspec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 2048, Soft: 2048}}, g.Config.Process.Rlimits)
+	assert.Equal(t, []string{"B=1", "A=1"}, g.Config.Process.Env)
+	assert.Equal(t, []string{"/proc/acpi", "/proc/kcore"}, g.Config.Linux.MaskedPaths)
+	assert.Nil(t, g.Config.Linux.Resources)
+	assert.Nil(t, g.Config.Hooks)
+
+	first, err := json.Marshal(g.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Normalize()
+	second, err := json.Marshal(g.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestSeccompArchitecture(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Config.Linux.Seccomp = &rspec.LinuxSeccomp{}
+
+	assert.Nil(t, g.SetSeccompArchitecture("amd64"))
+	assert.Nil(t, g.SetSeccompArchitecture("SCMP_ARCH_X86_64"))
+	assert.Nil(t, g.SetSeccompArchitecture("x86"))
+	assert.NotNil(t, g.SetSeccompArchitecture("amd46"))
+	assert.Equal(t, []rspec.Arch{rspec.ArchX86_64, rspec.ArchX86}, g.Config.Linux.Seccomp.Architectures)
+
+	assert.Nil(t, g.RemoveSeccompArchitecture("SCMP_ARCH_X86_64"))
+	assert.NotNil(t, g.RemoveSeccompArchitecture("amd46"))
+	assert.Equal(t, []rspec.Arch{rspec.ArchX86}, g.Config.Linux.Seccomp.Architectures)
+}
+
+func TestSeccompActions(t *testing.T) {
+	for name, action := range map[string]rspec.LinuxSeccompAction{
+		"allow":        rspec.ActAllow,
+		"errno":        rspec.ActErrno,
+		"kill":         rspec.ActKill,
+		"kill_process": rspec.ActKillProcess,
+		"kill_thread":  rspec.ActKillThread,
+		"log":          rspec.ActLog,
+		"notify":       seccomp.ActNotify,
+		"trace":        rspec.ActTrace,
+		"trap":         rspec.ActTrap,
+	} {
+		for _, input := range []string{name, string(action)} {
+			g, err := generate.New("linux")
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.Config.Linux.Seccomp = &rspec.LinuxSeccomp{}
+
+			assert.Nil(t, g.SetDefaultSeccompActionForce(input), input)
+			assert.Equal(t, action, g.Config.Linux.Seccomp.DefaultAction, input)
+
+			g.Config.Linux.Seccomp.DefaultAction = ""
+			assert.Nil(t, g.SetSyscallAction(seccomp.SyscallOpts{Action: input, Syscall: "getcwd"}), input)
+			if assert.Equal(t, 1, len(g.Config.Linux.Seccomp.Syscalls), input) {
+				assert.Equal(t, action, g.Config.Linux.Seccomp.Syscalls[0].Action, input)
+			}
+		}
+	}
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.SetDefaultSeccompAction("deny")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "kill_process")
+	}
+	assert.NotNil(t, g.SetSyscallAction(seccomp.SyscallOpts{Action: "deny", Syscall: "getcwd"}))
+}
+
+func TestSetLinuxCgroupsPathSystemd(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, g.SetLinuxCgroupsPathSystemd("system.slice", "runc", "abc"))
+	assert.Equal(t, "system.slice:runc:abc", g.Config.Linux.CgroupsPath)
+	path, err := cgroups.ResolveCgroupsPath(g.Config.Linux.CgroupsPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "/system.slice/runc-abc.scope", path)
+
+	assert.NotNil(t, g.SetLinuxCgroupsPathSystemd("system", "runc", "abc"))
+	assert.NotNil(t, g.SetLinuxCgroupsPathSystemd("system.slice", "runc", ""))
+	assert.NotNil(t, g.SetLinuxCgroupsPathSystemd("system.slice", "runc:x", "abc"))
+	assert.Equal(t, "system.slice:runc:abc", g.Config.Linux.CgroupsPath)
+}
+
+func TestGetLinuxNamespaces(t *testing.T) {
+	template := `{"ociVersion": "1.0.0", "linux": {"namespaces": [{"type": "pid"}, {"type": "network", "path": "/var/run/netns/test"}]}}`
+	g, err := generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespaces := g.GetLinuxNamespaces()
+	assert.Equal(t, []rspec.LinuxNamespace{
+		{Type: rspec.PIDNamespace},
+		{Type: rspec.NetworkNamespace, Path: "/var/run/netns/test"},
+	}, namespaces)
+	assert.True(t, g.HasLinuxNamespace("pid"))
+	assert.True(t, g.HasLinuxNamespace("network"))
+	assert.False(t, g.HasLinuxNamespace("user"))
+	assert.False(t, g.HasLinuxNamespace("unknown"))
+
+	// the returned namespaces are a copy
+	namespaces[0].Path = "/proc/1/ns/pid"
+	assert.Equal(t, "", g.GetLinuxNamespaces()[0].Path)
+
+	if !g.HasLinuxNamespace("user") {
+		assert.Nil(t, g.AddOrReplaceLinuxNamespace("user", ""))
+	}
+	assert.True(t, g.HasLinuxNamespace("user"))
+	assert.Nil(t, g.RemoveLinuxNamespace("pid"))
+	assert.False(t, g.HasLinuxNamespace("pid"))
+	assert.Equal(t, 2, len(g.GetLinuxNamespaces()))
+
+	var empty generate.Generator
+	assert.Nil(t, empty.GetLinuxNamespaces())
+	assert.False(t, empty.HasLinuxNamespace("pid"))
+}
+
+func TestSetRootPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestSetRootPath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+	assert.Nil(t, g.SetRootPath("rootfs/"))
+	assert.Equal(t, "rootfs", g.GetRootPath())
+	assert.Nil(t, g.SetRootPath(missing))
+	assert.Equal(t, missing, g.GetRootPath())
+
+	g.HostSpecific = true
+	g.BundleDir = dir
+	assert.NotNil(t, g.SetRootPath(missing))
+	assert.Equal(t, missing, g.GetRootPath())
+	assert.Nil(t, g.SetRootPath(dir+"/./"))
+	assert.Equal(t, dir, g.GetRootPath())
+}
+
+func TestSetRootPathTraversal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestSetRootPathTraversal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "a", "b", "bundle")
+	if err := os.MkdirAll(filepath.Join(bundle, "rootfs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "a", "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "a", "etc"), filepath.Join(bundle, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, g.SetRootPath("../../etc"))
+	assert.Equal(t, "../../etc", g.GetRootPath())
+
+	// the bundle is not the current directory
+	g.HostSpecific = true
+	g.BundleDir = bundle
+	assert.NotNil(t, g.SetRootPath("../../etc"))
+	assert.NotNil(t, g.SetRootPath(filepath.Join(bundle, "../../etc")))
+	assert.NotNil(t, g.SetRootPath(filepath.Join(dir, "a", "etc")))
+	assert.NotNil(t, g.SetRootPath("escape"))
+	assert.NotNil(t, g.SetRootPath("/etc"))
+	assert.Nil(t, g.SetRootPath("rootfs/../rootfs"))
+	assert.Equal(t, "rootfs", g.GetRootPath())
+	assert.Nil(t, g.SetRootPath(filepath.Join(bundle, "rootfs")))
+	assert.Equal(t, filepath.Join(bundle, "rootfs"), g.GetRootPath())
+	assert.Nil(t, g.SetRootPath("."))
+	assert.Equal(t, ".", g.GetRootPath())
+}
+
+func TestAddOrReplaceLinuxNamespaceHostSpecific(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bogus := "/proc/self/ns/bogus"
+	assert.Nil(t, g.AddOrReplaceLinuxNamespace("network", bogus))
+	assert.Contains(t, g.Config.Linux.Namespaces, rspec.LinuxNamespace{Type: rspec.NetworkNamespace, Path: bogus})
+
+	g.HostSpecific = true
+	assert.NotNil(t, g.AddOrReplaceLinuxNamespace("network", "/proc/self/ns/nte"))
+	assert.Contains(t, g.Config.Linux.Namespaces, rspec.LinuxNamespace{Type: rspec.NetworkNamespace, Path: bogus})
+	assert.Nil(t, g.AddOrReplaceLinuxNamespace("network", ""))
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	f, err := ioutil.TempFile("", "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	assert.NotNil(t, g.AddOrReplaceLinuxNamespace("network", f.Name()))
+	assert.Nil(t, g.AddOrReplaceLinuxNamespace("network", "/proc/self/ns/net"))
+	assert.Contains(t, g.Config.Linux.Namespaces, rspec.LinuxNamespace{Type: rspec.NetworkNamespace, Path: "/proc/self/ns/net"})
+}
+
+func TestSetHostname(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hostname := range []string{"", "mrsdalloway", "web-01.example.com", strings.Repeat("a", 63)} {
+		assert.Nil(t, g.SetHostname(hostname), hostname)
+		assert.Equal(t, hostname, g.Config.Hostname)
+	}
+	g.Config.Hostname = "mrsdalloway"
+	for _, hostname := range []string{
+		strings.Repeat("a", 64),
+		strings.Repeat("a.", 32) + "a",
+		"under_score",
+		"-leading",
+		"trailing-",
+		"double..dot",
+		"white space",
+	} {
+		assert.NotNil(t, g.SetHostname(hostname), hostname)
+		assert.Equal(t, "mrsdalloway", g.Config.Hostname)
+	}
+}
+
+func TestHugepageLimits(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.AddLinuxResourcesHugepageLimit("1GB", 1<<30)
+	g.AddLinuxResourcesHugepageLimit("2MB", 1<<20)
+	g.AddLinuxResourcesHugepageLimit("2MB", 2<<20)
+	assert.Equal(t, []rspec.LinuxHugepageLimit{
+		{Pagesize: "1GB", Limit: 1 << 30},
+		{Pagesize: "2MB", Limit: 2 << 20},
+	}, g.Config.Linux.Resources.HugepageLimits)
+
+	// duplicates from a template are merged as well
+	g.Config.Linux.Resources.HugepageLimits = append(g.Config.Linux.Resources.HugepageLimits, rspec.LinuxHugepageLimit{Pagesize: "1GB", Limit: 1})
+	g.AddLinuxResourcesHugepageLimit("1GB", 2<<30)
+	assert.Equal(t, []rspec.LinuxHugepageLimit{
+		{Pagesize: "1GB", Limit: 2 << 30},
+		{Pagesize: "2MB", Limit: 2 << 20},
+	}, g.Config.Linux.Resources.HugepageLimits)
+
+	g.DropLinuxResourcesHugepageLimit("1GB")
+	assert.Equal(t, []rspec.LinuxHugepageLimit{{Pagesize: "2MB", Limit: 2 << 20}}, g.Config.Linux.Resources.HugepageLimits)
+}
+
+func TestAddDevice(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearLinuxDevices()
+
+	for _, device := range []rspec.LinuxDevice{
+		{Path: "/dev/test", Type: "x", Major: 1, Minor: 3},
+		{Path: "/dev/test", Type: "", Major: 1, Minor: 3},
+		{Path: "/dev/test", Type: "c", Major: 0, Minor: 3},
+		{Path: "/dev/test", Type: "b", Major: -1, Minor: 3},
+		{Path: "/dev/test", Type: "c", Major: 1, Minor: -3},
+		{Path: "dev/test", Type: "c", Major: 1, Minor: 3},
+	} {
+		assert.NotNil(t, g.AddDevice(device), "%v", device)
+	}
+	assert.Equal(t, 0, len(g.Config.Linux.Devices))
+
+	assert.Nil(t, g.AddDevice(rspec.LinuxDevice{Path: "/dev/null", Type: "c", Major: 1, Minor: 3}))
+	assert.Nil(t, g.AddDevice(rspec.LinuxDevice{Path: "/dev/fifo", Type: "p"}))
+	assert.Nil(t, g.AddDevice(rspec.LinuxDevice{Path: "/dev/tty0", Type: "c", Major: 4, Minor: 0}))
+	assert.Nil(t, g.AddDevice(rspec.LinuxDevice{Path: "/dev/null", Type: "u", Major: 1, Minor: 5}))
+	assert.Equal(t, []rspec.LinuxDevice{
+		{Path: "/dev/null", Type: "u", Major: 1, Minor: 5},
+		{Path: "/dev/fifo", Type: "p"},
+		{Path: "/dev/tty0", Type: "c", Major: 4, Minor: 0},
+	}, g.Config.Linux.Devices)
+}
+
+func TestAddProcessArgs(t *testing.T) {
+	template := `{"ociVersion": "1.0.0", "process": {"cwd": "/", "args": ["/usr/bin/httpd"]}}`
+	g, err := generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.AddProcessArgs("-D", "FOREGROUND")
+	g.AddProcessArgs()
+	g.AddProcessArgs("-X")
+	assert.Equal(t, []string{"/usr/bin/httpd", "-D", "FOREGROUND", "-X"}, g.Config.Process.Args)
+
+	g.SetProcessArgs([]string{"sh"})
+	g.AddProcessArgs("-c", "true")
+	assert.Equal(t, []string{"sh", "-c", "true"}, g.Config.Process.Args)
+
+	g.Config.Process = nil
+	g.AddProcessArgs("true")
+	assert.Equal(t, []string{"true"}, g.Config.Process.Args)
+}
+
+func TestOOMSettings(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Config.Process = nil
+	g.Config.Linux.Resources = nil
+	assert.Empty(t, g.Validate())
+
+	g.SetLinuxResourcesMemoryDisableOOMKiller(true)
+	g.SetProcessOOMScoreAdj(500)
+	g.SetLinuxResourcesMemoryLimit(1 << 20)
+	assert.Equal(t, 1, len(g.Validate()))
+
+	data, err := json.Marshal(g.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec rspec.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, spec.Process.OOMScoreAdj) {
+		assert.Equal(t, 500, *spec.Process.OOMScoreAdj)
+	}
+	if assert.NotNil(t, spec.Linux.Resources.Memory.DisableOOMKiller) {
+		assert.True(t, *spec.Linux.Resources.Memory.DisableOOMKiller)
+	}
+	if assert.NotNil(t, spec.Linux.Resources.Memory.Limit) {
+		assert.Equal(t, int64(1<<20), *spec.Linux.Resources.Memory.Limit)
+	}
+
+	g.SetLinuxResourcesMemoryDisableOOMKiller(false)
+	assert.Empty(t, g.Validate())
+}
+
+func TestSortMounts(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearMounts()
+	assert.Empty(t, g.Validate())
+
+	for _, dest := range []string{"/a/b/c", "/dev", "/a", "/a/b/", "/dev/pts", "/x"} {
+		g.AddMount(rspec.Mount{Destination: dest, Type: "tmpfs", Source: "tmpfs"})
+	}
+	assert.Equal(t, 2, len(g.Validate()))
+
+	g.SortMounts()
+	var dests []string
+	for _, mount := range g.Mounts() {
+		dests = append(dests, mount.Destination)
+	}
+	assert.Equal(t, []string{"/dev", "/a", "/x", "/a/b/", "/dev/pts", "/a/b/c"}, dests)
+	assert.Empty(t, g.Validate())
+}
+
+func TestClearFromTemplate(t *testing.T) {
+	template := `{
+		"ociVersion": "1.0.0",
+		"process": {"cwd": "/", "rlimits": [{"type": "RLIMIT_NOFILE", "hard": 1024, "soft": 1024}]},
+		"mounts": [{"destination": "/proc", "type": "proc", "source": "proc"}],
+		"linux": {
+			"devices": [{"path": "/dev/fuse", "type": "c", "major": 10, "minor": 229}],
+			"sysctl": {"net.ipv4.ip_forward": "1"}
+		}
+	}`
+	g, err := generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.ClearLinuxDevices()
+	g.ClearMounts()
+	g.ClearLinuxSysctl()
+	g.ClearProcessRlimits()
+	assert.Empty(t, g.Config.Linux.Devices)
+	assert.Empty(t, g.Mounts())
+	assert.Empty(t, g.Config.Linux.Sysctl)
+	assert.Empty(t, g.Config.Process.Rlimits)
+
+	// the cleared lists can be refilled
+	assert.Nil(t, g.AddDevice(rspec.LinuxDevice{Path: "/dev/null", Type: "c", Major: 1, Minor: 3}))
+	g.AddLinuxSysctl("net.ipv4.ip_forward", "0")
+	assert.Equal(t, 1, len(g.Config.Linux.Devices))
+	assert.Equal(t, map[string]string{"net.ipv4.ip_forward": "0"}, g.Config.Linux.Sysctl)
+
+	// clearing a configuration without the fields is a no-op
+	g.Config.Process = nil
+	g.Config.Linux = nil
+	g.ClearLinuxDevices()
+	g.ClearLinuxSysctl()
+	g.ClearProcessRlimits()
+	assert.Nil(t, g.Config.Linux)
+	assert.Nil(t, g.Config.Process)
+}
+
+func TestString(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.AddAnnotation("com.example.key", "value")
+
+	s := g.String()
+	var buf bytes.Buffer
+	if err := g.Save(&buf, generate.ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, buf.String(), s)
+	assert.True(t, strings.HasPrefix(s, "{\n\t\"ociVersion\""))
+
+	// an empty linux section is omitted without changing the configuration
+	g.Config.Linux = &rspec.Linux{}
+	assert.NotContains(t, g.String(), "linux")
+	assert.NotNil(t, g.Config.Linux)
+
+	assert.Equal(t, "null", (&generate.Generator{}).String())
+}
+
+func TestAddProcessEnvMap(t *testing.T) {
+	env := map[string]string{
+		"ZED":   "z",
+		"ALPHA": "a",
+		"MIKE":  "m",
+		"TERM":  "dumb",
+	}
+
+	var first []string
+	for i := 0; i < 10; i++ {
+		g, err := generate.New("linux")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, g.AddProcessEnvMap(env))
+		if first == nil {
+			first = g.Config.Process.Env
+		}
+		assert.Equal(t, first, g.Config.Process.Env)
+	}
+	// TERM is replaced in place, the new variables are sorted
+	assert.Equal(t, []string{
+		"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"TERM=dumb",
+		"ALPHA=a",
+		"MIKE=m",
+		"ZED=z",
+	}, first)
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, g.AddProcessEnvMap(map[string]string{"VALID": "1", "IN-VALID": "2"}))
+	assert.Equal(t, 2, len(g.Config.Process.Env))
+}
+
+func TestApplyOverrides(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"process.args": ["sh", "-c", "true"],
+		"process.env": ["PATH=/bin", "TERM=xterm"],
+		"process.user.uid": 1000,
+		"process.terminal": true,
+		"annotations": {"org.example.key": "value"},
+		"linux.resources.memory.limit": 1048576,
+		"linux.resources.cpu.shares": 512
+	}`), &values); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, g.ApplyOverrides(values))
+	assert.Equal(t, []string{"sh", "-c", "true"}, g.Config.Process.Args)
+	assert.Equal(t, []string{"PATH=/bin", "TERM=xterm"}, g.Config.Process.Env)
+	assert.Equal(t, uint32(1000), g.Config.Process.User.UID)
+	assert.True(t, g.Config.Process.Terminal)
+	assert.Equal(t, "value", g.Config.Annotations["org.example.key"])
+	assert.Equal(t, int64(1048576), *g.Config.Linux.Resources.Memory.Limit)
+	assert.Equal(t, uint64(512), *g.Config.Linux.Resources.CPU.Shares)
+
+	assert.Nil(t, g.ApplyOverrides(map[string]interface{}{
+		"process.args": []string{"true"},
+		"process.env":  []string{"HOME=/root"},
+	}))
+	assert.Equal(t, []string{"true"}, g.Config.Process.Args)
+	assert.Equal(t, []string{"HOME=/root"}, g.Config.Process.Env)
+
+	for _, values := range []map[string]interface{}{
+		{"process.bogus": "value"},
+		{"process.args": "true", "process.cwd": "/tmp"},
+		{"process.cwd": "/tmp", "process.user.uid": -1},
+		{"process.cwd": "/tmp", "linux.resources.memory.limit": 1.5},
+		{"process.cwd": "/tmp", "root.readonly": "yes"},
+	} {
+		assert.NotNil(t, g.ApplyOverrides(values), "%v", values)
+		assert.Equal(t, "/", g.Config.Process.Cwd)
+	}
+	assert.NotNil(t, g.ApplyOverrides(map[string]interface{}{"process.env": []string{"1=bad"}}))
+	assert.Equal(t, []string{"HOME=/root"}, g.Config.Process.Env)
+	assert.Contains(t, generate.OverridePaths(), "linux.resources.memory.limit")
+}
+
+func TestAddProcessRlimits(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearProcessRlimits()
+
+	assert.Nil(t, g.AddProcessRlimits("RLIMIT_CORE", 0, 0))
+	assert.Nil(t, g.AddProcessRlimits("nofile", 1024, 512))
+	assert.Nil(t, g.AddProcessRlimits("NPROC", 100, 100))
+	assert.Nil(t, g.AddProcessRlimits("core", 10, 10))
+	assert.Equal(t, []rspec.POSIXRlimit{
+		{Type: "RLIMIT_CORE", Hard: 10, Soft: 10},
+		{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 512},
+		{Type: "RLIMIT_NPROC", Hard: 100, Soft: 100},
+	}, g.Config.Process.Rlimits)
+
+	for _, name := range []string{"", "bogus", "RLIMIT_BOGUS", "RLIMIT_"} {
+		assert.NotNil(t, g.AddProcessRlimits(name, 1, 1), name)
+	}
+	assert.Len(t, g.Config.Process.Rlimits, 3)
+
+	g.RemoveProcessRlimits("nproc")
+	g.RemoveProcessRlimits("RLIMIT_CORE")
+	assert.Equal(t, []rspec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 512}}, g.Config.Process.Rlimits)
+}
+
+func TestSetProcessCommandLine(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, g.SetProcessCommandLine("sh -c true"))
+	assert.Equal(t, "", g.Config.Process.CommandLine)
+
+	g, err = generate.New("windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, g.SetProcessCommandLine(`cmd /c "echo hello"`))
+	assert.Empty(t, g.Validate())
+
+	data, err := json.Marshal(g.Config.Process)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var process map[string]interface{}
+	if err := json.Unmarshal(data, &process); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `cmd /c "echo hello"`, process["commandLine"])
+	assert.NotContains(t, process, "args")
+
+	g.SetProcessArgs([]string{"cmd"})
+	assert.Len(t, g.Validate(), 1)
+	assert.Nil(t, g.Save(ioutil.Discard, generate.ExportOptions{}))
+
+	// only windows runs process.commandLine
+	template := `{"ociVersion": "1.0.0", "process": {"args": ["sh"], "commandLine": "sh -c true", "cwd": "/"}}`
+	g, err = generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, g.Save(ioutil.Discard, generate.ExportOptions{}))
+	_, err = generate.BuilderFor(&g).Build()
+	assert.NotNil(t, err)
+}
+
+func TestSetProcessUser(t *testing.T) {
+	individual, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	individual.SetProcessUID(1000)
+	individual.SetProcessGID(100)
+	individual.AddProcessAdditionalGid(27)
+	individual.AddProcessAdditionalGid(10)
+	individual.SetProcessUmask(022)
+
+	composed, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	umask := uint32(022)
+	composed.SetProcessUser(1000, 100, []uint32{27, 10, 27}, &umask)
+	umask = 077
+	assert.Equal(t, individual.Config.Process.User, composed.Config.Process.User)
+
+	composed.SetProcessUser(0, 0, nil, nil)
+	assert.Equal(t, rspec.User{}, composed.Config.Process.User)
+}
+
+func TestAddBindMount(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearMounts()
+
+	assert.Nil(t, g.AddBindMount("/volumes/data", "/data", []string{"ro"}))
+	assert.Nil(t, g.AddBindMount("/volumes/log", "/log", []string{"bind"}))
+	assert.Empty(t, g.Validate())
+	assert.Equal(t, []rspec.Mount{
+		{Destination: "/data", Type: "bind", Source: "/volumes/data", Options: []string{"ro"}},
+		{Destination: "/log", Type: "bind", Source: "/volumes/log", Options: []string{"bind"}},
+	}, g.Config.Mounts)
+
+	assert.Nil(t, g.AddBindMount("volumes/cache", "/cache", nil))
+	assert.Len(t, g.Config.Mounts, 3)
+	assert.Len(t, g.Validate(), 1)
+	assert.NotNil(t, g.AddBindMount("/volumes/data", "", nil))
+
+	g.HostSpecific = true
+	assert.NotNil(t, g.AddBindMount("volumes/cache", "/cache2", nil))
+	assert.Nil(t, g.AddBindMount("/volumes/cache", "/cache2", nil))
+	assert.Len(t, g.Config.Mounts, 4)
+
+	// other mounts have no constraint on their source
+	g.AddMount(rspec.Mount{Destination: "/proc", Type: "proc", Source: "proc"})
+	assert.Len(t, g.Validate(), 1)
+}
+
+func TestSetLinuxMountLabel(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, label := range []string{
+		"",
+		"system_u:object_r:svirt_sandbox_file_t:s0:c715,c811",
+		"system_u:object_r:container_file_t:s0-s0:c0.c1023",
+		"system_u:object_r:container_file_t:s0",
+		"system_u:object_r:container_file_t",
+		"_",
+	} {
+		assert.Nil(t, g.SetLinuxMountLabel(label), label)
+		assert.Equal(t, label, g.GetLinuxMountLabel())
+	}
+
+	g.Config.Linux.MountLabel = "system_u:object_r:container_file_t:s0"
+	for _, label := range []string{
+		"system_u:object_r",
+		"system_u::container_file_t:s0",
+		"system_u:object_r:container file_t:s0",
+		"system_u:object_r:container_file_t:",
+		"system_u:object_r:container_file_t:c1,c2",
+		"system_u:object_r:container_file_t:s0:c1;c2",
+	} {
+		assert.NotNil(t, g.SetLinuxMountLabel(label), label)
+		assert.Equal(t, "system_u:object_r:container_file_t:s0", g.GetLinuxMountLabel())
+	}
+
+	g = generate.Generator{}
+	assert.Equal(t, "", g.GetLinuxMountLabel())
+}
+
+func TestSetEnvExpansion(t *testing.T) {
+	os.Setenv("RUNTIME_TOOLS_TEST_HOME", "/home/test")
+	defer os.Unsetenv("RUNTIME_TOOLS_TEST_HOME")
+	os.Unsetenv("RUNTIME_TOOLS_TEST_UNDEFINED")
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearProcessEnv()
+
+	assert.Nil(t, g.AddProcessEnv("LITERAL", "$RUNTIME_TOOLS_TEST_HOME"))
+	g.SetEnvExpansion(true)
+	assert.Nil(t, g.AddProcessEnv("HOME", "$RUNTIME_TOOLS_TEST_HOME"))
+	assert.Nil(t, g.AddProcessEnv("BIN", "${RUNTIME_TOOLS_TEST_HOME}/bin"))
+	assert.Nil(t, g.AddProcessEnv("UNDEFINED", "x${RUNTIME_TOOLS_TEST_UNDEFINED}y"))
+	assert.Nil(t, g.AddProcessEnv("ESCAPED", "$$RUNTIME_TOOLS_TEST_HOME"))
+	assert.Nil(t, g.AddMultipleProcessEnv([]string{"MULTIPLE=$RUNTIME_TOOLS_TEST_HOME=1"}))
+	assert.Nil(t, g.AddProcessEnvMap(map[string]string{"MAP": "${RUNTIME_TOOLS_TEST_HOME}"}))
+	g.SetEnvExpansion(false)
+	assert.Nil(t, g.AddProcessEnv("OFF", "${RUNTIME_TOOLS_TEST_HOME}"))
+
+	assert.Equal(t, []string{
+		"LITERAL=$RUNTIME_TOOLS_TEST_HOME",
+		"HOME=/home/test",
+		"BIN=/home/test/bin",
+		"UNDEFINED=xy",
+		"ESCAPED=$RUNTIME_TOOLS_TEST_HOME",
+		"MULTIPLE=/home/test=1",
+		"MAP=/home/test",
+		"OFF=${RUNTIME_TOOLS_TEST_HOME}",
+	}, g.Config.Process.Env)
+}
+
+func TestDropProcessCapabilitySet(t *testing.T) {
+	template := `{"ociVersion": "1.0.0", "process": {"cwd": "/", "capabilities": {
+		"bounding": ["CAP_CHOWN", "CAP_KILL", "cap_chown", "CAP_NET_RAW", "CAP_KILL"],
+		"effective": ["CAP_CHOWN", "CAP_KILL"],
+		"permitted": ["CAP_CHOWN", "CAP_KILL"]}}}`
+	g, err := generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, g.DropProcessCapabilityEffective("CAP_KILL"))
+	assert.Equal(t, []string{"CAP_CHOWN"}, g.Config.Process.Capabilities.Effective)
+	assert.Equal(t, []string{"CAP_CHOWN", "CAP_KILL"}, g.Config.Process.Capabilities.Permitted)
+	assert.Equal(t, []string{"CAP_CHOWN", "CAP_KILL", "cap_chown", "CAP_NET_RAW", "CAP_KILL"}, g.Config.Process.Capabilities.Bounding)
+
+	assert.Nil(t, g.DropProcessCapabilityBounding("cap_chown"))
+	assert.Equal(t, []string{"CAP_KILL", "CAP_NET_RAW"}, g.Config.Process.Capabilities.Bounding)
+	assert.Equal(t, []string{"CAP_CHOWN", "CAP_KILL"}, g.Config.Process.Capabilities.Permitted)
+
+	assert.Nil(t, g.DropProcessCapability("CAP_NET_RAW"))
+	assert.Equal(t, []string{"CAP_KILL"}, g.Config.Process.Capabilities.Bounding)
+	assert.Equal(t, []string{"CAP_CHOWN"}, g.Config.Process.Capabilities.Effective)
+}
+
+func TestSetHooks(t *testing.T) {
+	template := `{"ociVersion": "1.0.0", "hooks": {"poststop": [{"path": "/bin/cleanup"}]}}`
+	g, err := generate.NewFromTemplate(strings.NewReader(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := 5
+	hooks := &rspec.Hooks{
+		Prestart:        []rspec.Hook{{Path: "/bin/setup", Args: []string{"setup", "--net"}, Timeout: &timeout}},
+		CreateRuntime:   []rspec.Hook{{Path: "/bin/create-runtime", Args: []string{"create-runtime"}}},
+		CreateContainer: []rspec.Hook{{Path: "/bin/create-container", Env: []string{"STAGE=create"}}},
+		StartContainer:  []rspec.Hook{{Path: "/bin/start-container"}},
+		Poststart:       []rspec.Hook{{Path: "/bin/notify", Env: []string{"FOO=bar"}}},
+	}
+	assert.Nil(t, g.SetHooks(hooks))
+	assert.Equal(t, hooks, g.Config.Hooks)
+
+	// the hooks are copied
+	hooks.Prestart[0].Args[1] = "--ipc"
+	*hooks.Prestart[0].Timeout = 10
+	hooks.Poststart[0].Env[0] = "FOO=baz"
+	hooks.CreateRuntime[0].Args[0] = "changed"
+	hooks.CreateContainer[0].Env[0] = "STAGE=changed"
+	hooks.StartContainer[0].Path = "/bin/changed"
+	assert.Equal(t, []string{"setup", "--net"}, g.Config.Hooks.Prestart[0].Args)
+	assert.Equal(t, []string{"create-runtime"}, g.Config.Hooks.CreateRuntime[0].Args)
+	assert.Equal(t, []string{"STAGE=create"}, g.Config.Hooks.CreateContainer[0].Env)
+	assert.Equal(t, "/bin/start-container", g.Config.Hooks.StartContainer[0].Path)
+	assert.Equal(t, 5, *g.Config.Hooks.Prestart[0].Timeout)
+	assert.Equal(t, []string{"FOO=bar"}, g.Config.Hooks.Poststart[0].Env)
+
+	err = g.SetHooks(&rspec.Hooks{Poststop: []rspec.Hook{{Path: "/bin/cleanup"}, {Args: []string{"cleanup"}}}})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "poststop hook 1")
+	}
+	assert.Equal(t, "/bin/setup", g.Config.Hooks.Prestart[0].Path)
+
+	err = g.SetHooks(&rspec.Hooks{StartContainer: []rspec.Hook{{Args: []string{"start"}}}})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "startContainer hook 0")
+	}
+
+	g.ClearHooks()
+	assert.Nil(t, g.Config.Hooks)
+
+	assert.Nil(t, g.SetHooks(&rspec.Hooks{Poststop: []rspec.Hook{{Path: "/bin/cleanup"}}}))
+	assert.Nil(t, g.SetHooks(nil))
+	assert.Nil(t, g.Config.Hooks)
+}
+
+func TestSetOCIVersion(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, g.SetOCIVersion("0.9.0"))
+	assert.NotNil(t, g.SetOCIVersion("1.0"))
+	assert.Equal(t, rspec.Version, g.Config.Version)
+
+	g.Config.Linux.Resources = &rspec.LinuxResources{Unified: map[string]string{"memory.high": "1000000"}}
+	assert.Empty(t, g.Validate())
+
+	assert.Nil(t, g.SetOCIVersion("1.0.0"))
+	assert.Equal(t, "1.0.0", g.Config.Version)
+	warnings := g.Validate()
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0], "linux.resources.unified")
+	}
+
+	assert.Nil(t, g.SetOCIVersion(rspec.Version))
+	assert.Empty(t, g.Validate())
+}
+
+func TestAddAllProcessCapabilities(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Config.Process.ApparmorProfile = "docker-default"
+
+	assert.Nil(t, g.AddAllProcessCapabilities())
+	count := len(validate.AllCapabilityStrings())
+	caps := g.Config.Process.Capabilities
+	for _, set := range [][]string{caps.Bounding, caps.Effective, caps.Inheritable, caps.Permitted, caps.Ambient} {
+		assert.Len(t, set, count)
+	}
+	assert.Equal(t, "docker-default", g.Config.Process.ApparmorProfile)
+	assert.NotNil(t, g.Config.Linux.Seccomp)
+}
+
+func TestParseCPUList(t *testing.T) {
+	for _, c := range []struct {
+		list     string
+		expected []int
+	}{
+		{"", nil},
+		{"3", []int{3}},
+		{"0-3", []int{0, 1, 2, 3}},
+		{"0-3,7", []int{0, 1, 2, 3, 7}},
+		{"7,1-2,2", []int{1, 2, 7}},
+		{" 4-4\n", []int{4}},
+	} {
+		cpus, err := generate.ParseCPUList(c.list)
+		assert.Nil(t, err, c.list)
+		assert.Equal(t, c.expected, cpus, c.list)
+	}
+
+	for _, list := range []string{"0--3", "a,b", "1,", ",1", "3-1", "-1", "1-", "+1", "0-3 7"} {
+		_, err := generate.ParseCPUList(list)
+		assert.NotNil(t, err, list)
+	}
+}
+
+func TestSetLinuxResourcesCPUCpus(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, g.SetLinuxResourcesCPUCpus("0-3,7"))
+	assert.Nil(t, g.SetLinuxResourcesCPUMems("0"))
+	assert.NotNil(t, g.SetLinuxResourcesCPUCpus("0--3"))
+	assert.NotNil(t, g.SetLinuxResourcesCPUMems("a,b"))
+	// large ranges are checked without being expanded
+	assert.Nil(t, g.SetLinuxResourcesCPUMems("0-2000000000"))
+	assert.Nil(t, g.SetLinuxResourcesCPUCpus("0-3,7"))
+	assert.Equal(t, "0-3,7", g.Config.Linux.Resources.CPU.Cpus)
+	assert.Equal(t, "0-2000000000", g.Config.Linux.Resources.CPU.Mems)
+	assert.Nil(t, g.SetLinuxResourcesCPUMems("0"))
+	assert.Equal(t, "0", g.Config.Linux.Resources.CPU.Mems)
+}
+
+func TestAddMountOptionsPassThrough(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ClearMounts()
+
+	options := []string{"trans=virtio", "cache=mmap", "x-lima.custom"}
+	g.AddMount(rspec.Mount{Destination: "/mnt/share", Type: "9p", Source: "share", Options: options})
+	assert.Nil(t, g.AddBindMount("/src", "/mnt/bind", []string{"cached", "ro"}))
+	options[1] = "cache=none"
+
+	var buf bytes.Buffer
+	if err := g.Save(&buf, generate.ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var spec rspec.Spec
+	if err := json.Unmarshal(buf.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, spec.Mounts, 2) {
+		assert.Equal(t, []string{"trans=virtio", "cache=mmap", "x-lima.custom"}, spec.Mounts[0].Options)
+		assert.Equal(t, []string{"cached", "ro"}, spec.Mounts[1].Options)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	spec, err := generate.NewBuilder("linux").
+		WithHostname("web").
+		WithArgs("sh", "-c", "echo hello").
+		WithEnv("FOO", "bar").
+		WithCapAdd("net_admin").
+		WithCapDrop("CAP_KILL").
+		WithBindMount("/src", "/dst", "ro").
+		WithCPUs("0-1").
+		Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "web", spec.Hostname)
+	assert.Equal(t, []string{"sh", "-c", "echo hello"}, spec.Process.Args)
+	assert.Contains(t, spec.Process.Env, "FOO=bar")
+	assert.Contains(t, spec.Process.Capabilities.Bounding, "CAP_NET_ADMIN")
+	assert.NotContains(t, spec.Process.Capabilities.Bounding, "CAP_KILL")
+	assert.Equal(t, "0-1", spec.Linux.Resources.CPU.Cpus)
+
+	b := generate.NewBuilder("linux").
+		WithHostname("-invalid-").
+		WithArgs("true").
+		WithCapAdd("BOGUS").
+		WithCPUs("0--3").
+		WithEnv("FOO", "bar")
+	_, err = b.Build()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "WithHostname")
+		assert.Contains(t, err.Error(), "WithCapAdd")
+		assert.Contains(t, err.Error(), "WithCPUs")
+		assert.NotContains(t, err.Error(), "WithEnv")
+	}
+	// the valid calls are still applied
+	assert.Equal(t, []string{"true"}, b.Generator().Config.Process.Args)
+
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err = generate.BuilderFor(&g).WithCwd("/work").Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "/work", g.Config.Process.Cwd)
+
+	// the built configuration is a copy
+	spec.Process.Args[0] = "changed"
+	assert.NotEqual(t, "changed", g.Config.Process.Args[0])
+
+	_, err = generate.NewBuilder("plan9").Build()
+	assert.NotNil(t, err)
+
+	_, err = generate.BuilderFor(&generate.Generator{}).Build()
+	assert.NotNil(t, err)
+}
+
+func TestSetLinuxIDMappings(t *testing.T) {
+	g, err := generate.New("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mappings := []rspec.LinuxIDMapping{}
+	for i := uint32(0); i < 5; i++ {
+		mappings = append(mappings, rspec.LinuxIDMapping{ContainerID: i * 1000, HostID: 100000 + i*1000, Size: 1000})
+	}
+	assert.Nil(t, g.SetLinuxUIDMappings(mappings))
+	assert.Nil(t, g.SetLinuxGIDMappings(mappings))
+	assert.Equal(t, mappings, g.Config.Linux.UIDMappings)
+	assert.Equal(t, mappings, g.Config.Linux.GIDMappings)
+
+	// the kernel accepts at most 5 lines in uid_map and gid_map
+	tooMany := append(mappings, rspec.LinuxIDMapping{ContainerID: 5000, HostID: 105000, Size: 1000})
+	assert.NotNil(t, g.SetLinuxUIDMappings(tooMany))
+	assert.NotNil(t, g.SetLinuxGIDMappings(tooMany))
+	assert.Equal(t, mappings, g.Config.Linux.UIDMappings)
+	assert.Equal(t, mappings, g.Config.Linux.GIDMappings)
+
+	// the mappings are copied
+	mappings[0].Size = 1
+	assert.Equal(t, uint32(1000), g.Config.Linux.UIDMappings[0].Size)
 }
@@ -0,0 +1,27 @@
+//go:build linux
+// +build linux
+
+package generate
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// isNamespaceFile returns true if path is a namespace file, like
+// /proc/<pid>/ns/net, or a bind mount of one, like /run/netns/<name>.
+// Namespace files all live on the same filesystem as those of the
+// current process.
+func isNamespaceFile(path string) bool {
+	if matched, _ := filepath.Match("/proc/*/ns/*", path); matched {
+		return true
+	}
+	var st, self syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return false
+	}
+	if err := syscall.Stat("/proc/self/ns/net", &self); err != nil {
+		return false
+	}
+	return st.Mode&syscall.S_IFMT == syscall.S_IFREG && st.Dev == self.Dev
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package generate
+
+import (
+	"path/filepath"
+)
+
+// isNamespaceFile returns true if path is a namespace file, like
+// /proc/<pid>/ns/net.
+func isNamespaceFile(path string) bool {
+	matched, _ := filepath.Match("/proc/*/ns/*", path)
+	return matched
+}
@@ -0,0 +1,179 @@
+package generate
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// sortedUnique returns the sorted list of the distinct strings in s, or nil
+// if s is empty.
+func sortedUnique(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, item := range s {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// cleanPaths cleans every path in s.
+func cleanPaths(s []string) []string {
+	for i, path := range s {
+		if path != "" {
+			s[i] = filepath.Clean(path)
+		}
+	}
+	return s
+}
+
+// isEmpty returns true if v has no fields set.
+func isEmpty(v interface{}) bool {
+	data, err := json.Marshal(v)
+	return err == nil && string(data) == "{}"
+}
+
+// Normalize rewrites g.Config into a canonical form, so that equivalent
+// configurations are saved identically.  Lists whose order has no meaning
+// are sorted and deduplicated, paths are cleaned, and optional fields which
+// hold no settings are removed.  Lists whose order matters, like the
+// process arguments and environment, the mounts, hooks and the device
+// cgroup rules, are kept as they are.  Normalizing a configuration twice
+// gives the same result.
+func (g *Generator) Normalize() {
+	if g.Config == nil {
+		return
+	}
+
+	if g.Config.Root != nil && g.Config.Root.Path != "" {
+		g.Config.Root.Path = filepath.Clean(g.Config.Root.Path)
+	}
+
+	for i := range g.Config.Mounts {
+		if g.Config.Mounts[i].Destination != "" {
+			g.Config.Mounts[i].Destination = filepath.Clean(g.Config.Mounts[i].Destination)
+		}
+	}
+
+	if g.Config.Hooks != nil && isEmpty(g.Config.Hooks) {
+		g.Config.Hooks = nil
+	}
+
+	if len(g.Config.Annotations) == 0 {
+		g.Config.Annotations = nil
+	}
+
+	g.normalizeProcess()
+	g.normalizeLinux()
+}
+
+func (g *Generator) normalizeProcess() {
+	process := g.Config.Process
+	if process == nil {
+		return
+	}
+
+	if process.Cwd != "" {
+		process.Cwd = filepath.Clean(process.Cwd)
+	}
+
+	if len(process.User.AdditionalGids) == 0 {
+		process.User.AdditionalGids = nil
+	} else {
+		sort.Slice(process.User.AdditionalGids, func(i, j int) bool {
+			return process.User.AdditionalGids[i] < process.User.AdditionalGids[j]
+		})
+		gids := process.User.AdditionalGids[:1]
+		for _, gid := range process.User.AdditionalGids[1:] {
+			if gid != gids[len(gids)-1] {
+				gids = append(gids, gid)
+			}
+		}
+		process.User.AdditionalGids = gids
+	}
+
+	if caps := process.Capabilities; caps != nil {
+		caps.Bounding = sortedUnique(caps.Bounding)
+		caps.Effective = sortedUnique(caps.Effective)
+		caps.Inheritable = sortedUnique(caps.Inheritable)
+		caps.Permitted = sortedUnique(caps.Permitted)
+		caps.Ambient = sortedUnique(caps.Ambient)
+	}
+
+	if len(process.Rlimits) == 0 {
+		process.Rlimits = nil
+	} else {
+		// a later rlimit of the same type overrides an earlier one
+		rlimits := map[string]rspec.POSIXRlimit{}
+		for _, rlimit := range process.Rlimits {
+			rlimits[rlimit.Type] = rlimit
+		}
+		process.Rlimits = process.Rlimits[:0]
+		for _, rlimit := range rlimits {
+			process.Rlimits = append(process.Rlimits, rlimit)
+		}
+		sort.Slice(process.Rlimits, func(i, j int) bool {
+			return process.Rlimits[i].Type < process.Rlimits[j].Type
+		})
+	}
+}
+
+func (g *Generator) normalizeLinux() {
+	linux := g.Config.Linux
+	if linux == nil {
+		return
+	}
+
+	linux.MaskedPaths = sortedUnique(cleanPaths(linux.MaskedPaths))
+	linux.ReadonlyPaths = sortedUnique(cleanPaths(linux.ReadonlyPaths))
+
+	if len(linux.Sysctl) == 0 {
+		linux.Sysctl = nil
+	}
+
+	if len(linux.Namespaces) == 0 {
+		linux.Namespaces = nil
+	} else {
+		sort.SliceStable(linux.Namespaces, func(i, j int) bool {
+			return linux.Namespaces[i].Type < linux.Namespaces[j].Type
+		})
+	}
+
+	if len(linux.Devices) == 0 {
+		linux.Devices = nil
+	} else {
+		for i := range linux.Devices {
+			linux.Devices[i].Path = filepath.Clean(linux.Devices[i].Path)
+		}
+		sort.SliceStable(linux.Devices, func(i, j int) bool {
+			return linux.Devices[i].Path < linux.Devices[j].Path
+		})
+	}
+
+	if linux.Resources != nil && isEmpty(linux.Resources) {
+		linux.Resources = nil
+	}
+
+	if seccomp := linux.Seccomp; seccomp != nil {
+		var archs []string
+		for _, arch := range seccomp.Architectures {
+			archs = append(archs, string(arch))
+		}
+		seccomp.Architectures = nil
+		for _, arch := range sortedUnique(archs) {
+			seccomp.Architectures = append(seccomp.Architectures, rspec.Arch(arch))
+		}
+		for i := range seccomp.Syscalls {
+			seccomp.Syscalls[i].Names = sortedUnique(seccomp.Syscalls[i].Names)
+		}
+	}
+}
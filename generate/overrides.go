@@ -0,0 +1,395 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// override converts the value of an override and returns the function
+// applying it to a generator.
+type override func(value interface{}) (func(g *Generator) error, error)
+
+// overrides maps the field paths supported by ApplyOverrides to their
+// overrides.  The paths use the JSON names of the fields.
+var overrides = map[string]override{
+	"annotations": func(value interface{}) (func(g *Generator) error, error) {
+		annotations, err := stringMapValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			for key, value := range annotations {
+				g.AddAnnotation(key, value)
+			}
+			return nil
+		}, nil
+	},
+	"hostname": func(value interface{}) (func(g *Generator) error, error) {
+		hostname, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			return g.SetHostname(hostname)
+		}, nil
+	},
+	"linux.cgroupsPath": func(value interface{}) (func(g *Generator) error, error) {
+		path, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxCgroupsPath(path)
+			return nil
+		}, nil
+	},
+	"linux.resources.cpu.cpus": func(value interface{}) (func(g *Generator) error, error) {
+		cpus, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			return g.SetLinuxResourcesCPUCpus(cpus)
+		}, nil
+	},
+	"linux.resources.cpu.mems": func(value interface{}) (func(g *Generator) error, error) {
+		mems, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			return g.SetLinuxResourcesCPUMems(mems)
+		}, nil
+	},
+	"linux.resources.cpu.period": func(value interface{}) (func(g *Generator) error, error) {
+		period, err := uintValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxResourcesCPUPeriod(period)
+			return nil
+		}, nil
+	},
+	"linux.resources.cpu.quota": func(value interface{}) (func(g *Generator) error, error) {
+		quota, err := intValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxResourcesCPUQuota(quota)
+			return nil
+		}, nil
+	},
+	"linux.resources.cpu.shares": func(value interface{}) (func(g *Generator) error, error) {
+		shares, err := uintValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxResourcesCPUShares(shares)
+			return nil
+		}, nil
+	},
+	"linux.resources.memory.limit": func(value interface{}) (func(g *Generator) error, error) {
+		limit, err := intValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxResourcesMemoryLimit(limit)
+			return nil
+		}, nil
+	},
+	"linux.resources.memory.reservation": func(value interface{}) (func(g *Generator) error, error) {
+		reservation, err := intValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxResourcesMemoryReservation(reservation)
+			return nil
+		}, nil
+	},
+	"linux.resources.memory.swap": func(value interface{}) (func(g *Generator) error, error) {
+		swap, err := intValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetLinuxResourcesMemorySwap(swap)
+			return nil
+		}, nil
+	},
+	"linux.resources.pids.limit": func(value interface{}) (func(g *Generator) error, error) {
+		limit, err := intValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			return g.SetLinuxResourcesPidsLimit(limit)
+		}, nil
+	},
+	"process.args": func(value interface{}) (func(g *Generator) error, error) {
+		args, err := stringsValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetProcessArgs(args)
+			return nil
+		}, nil
+	},
+	"process.cwd": func(value interface{}) (func(g *Generator) error, error) {
+		cwd, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetProcessCwd(cwd)
+			return nil
+		}, nil
+	},
+	"process.env": func(value interface{}) (func(g *Generator) error, error) {
+		env, err := stringsValue(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range env {
+			split := strings.SplitN(val, "=", 2)
+			if len(split) != 2 {
+				return nil, fmt.Errorf("environment variable %q must contain '='", val)
+			}
+			if err := envNameValid(split[0]); err != nil {
+				return nil, err
+			}
+		}
+		return func(g *Generator) error {
+			g.ClearProcessEnv()
+			return g.AddMultipleProcessEnv(env)
+		}, nil
+	},
+	"process.noNewPrivileges": func(value interface{}) (func(g *Generator) error, error) {
+		b, err := boolValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetProcessNoNewPrivileges(b)
+			return nil
+		}, nil
+	},
+	"process.oomScoreAdj": func(value interface{}) (func(g *Generator) error, error) {
+		adj, err := intValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetProcessOOMScoreAdj(int(adj))
+			return nil
+		}, nil
+	},
+	"process.terminal": func(value interface{}) (func(g *Generator) error, error) {
+		b, err := boolValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetProcessTerminal(b)
+			return nil
+		}, nil
+	},
+	"process.user.gid": func(value interface{}) (func(g *Generator) error, error) {
+		gid, err := uintValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if gid > math.MaxUint32 {
+			return nil, fmt.Errorf("%d is out of range", gid)
+		}
+		return func(g *Generator) error {
+			g.SetProcessGID(uint32(gid))
+			return nil
+		}, nil
+	},
+	"process.user.uid": func(value interface{}) (func(g *Generator) error, error) {
+		uid, err := uintValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if uid > math.MaxUint32 {
+			return nil, fmt.Errorf("%d is out of range", uid)
+		}
+		return func(g *Generator) error {
+			g.SetProcessUID(uint32(uid))
+			return nil
+		}, nil
+	},
+	"root.path": func(value interface{}) (func(g *Generator) error, error) {
+		path, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			return g.SetRootPath(path)
+		}, nil
+	},
+	"root.readonly": func(value interface{}) (func(g *Generator) error, error) {
+		b, err := boolValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Generator) error {
+			g.SetRootReadonly(b)
+			return nil
+		}, nil
+	},
+}
+
+// OverridePaths returns the sorted field paths supported by ApplyOverrides.
+func OverridePaths() []string {
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ApplyOverrides sets the fields of g.Config named by the keys of values,
+// like "process.args" or "linux.resources.memory.limit", see
+// OverridePaths.  The values may be Go values or the result of decoding
+// JSON or YAML, so numbers may be float64 and lists []interface{}.  Lists
+// replace the current ones.  Overrides are applied in the sorted order of
+// their paths.  Unknown paths and values of the wrong type are reported
+// before anything is changed; if a setter rejects a value, the overrides
+// sorted before it have already been applied.
+func (g *Generator) ApplyOverrides(values map[string]interface{}) error {
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	apply := make([]func(g *Generator) error, 0, len(paths))
+	for _, path := range paths {
+		o, ok := overrides[path]
+		if !ok {
+			return fmt.Errorf("unsupported override path %q, must be one of %s", path, strings.Join(OverridePaths(), ", "))
+		}
+		f, err := o(values[path])
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", path, err)
+		}
+		apply = append(apply, f)
+	}
+
+	for i, f := range apply {
+		if err := f(g); err != nil {
+			return fmt.Errorf("%s: %v", paths[i], err)
+		}
+	}
+	return nil
+}
+
+func stringValue(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%v is not a string", value)
+	}
+	return s, nil
+}
+
+func stringsValue(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []string:
+		return copyStrings(v), nil
+	case []interface{}:
+		s := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := stringValue(item)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, str)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("%v is not a list of strings", value)
+	}
+}
+
+func stringMapValue(value interface{}) (map[string]string, error) {
+	switch v := value.(type) {
+	case map[string]string:
+		m := make(map[string]string, len(v))
+		for key, value := range v {
+			m[key] = value
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]string, len(v))
+		for key, item := range v {
+			str, err := stringValue(item)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = str
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("%v is not a map of strings", value)
+	}
+}
+
+func boolValue(value interface{}) (bool, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%v is not a boolean", value)
+	}
+	return b, nil
+}
+
+func intValue(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("%d is out of range", v)
+		}
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(v), nil
+	case json.Number:
+		return v.Int64()
+	default:
+		return 0, fmt.Errorf("%v is not an integer", value)
+	}
+}
+
+func uintValue(value interface{}) (uint64, error) {
+	if v, ok := value.(uint64); ok {
+		return v, nil
+	}
+	i, err := intValue(value)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("%d is negative", i)
+	}
+	return uint64(i), nil
+}
@@ -2,6 +2,7 @@ package seccomp
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -29,7 +30,10 @@ func ParseSyscallFlag(args SyscallOpts, config *rspec.LinuxSeccomp) error {
 		arguments = []string{args.Action, args.Syscall}
 	}
 
-	action, _ := parseAction(arguments[0])
+	action, err := parseAction(arguments[0])
+	if err != nil {
+		return err
+	}
 	if action == config.DefaultAction && args.argsAreEmpty() {
 		// default already set, no need to make changes
 		return nil
@@ -68,21 +72,37 @@ func ParseSyscallFlag(args SyscallOpts, config *rspec.LinuxSeccomp) error {
 	return nil
 }
 
+// ActNotify is the SCMP_ACT_NOTIFY action, which forwards the syscall to
+// a seccomp agent.  It is missing from the vendored runtime-spec.
+const ActNotify rspec.LinuxSeccompAction = "SCMP_ACT_NOTIFY"
+
 var actions = map[string]rspec.LinuxSeccompAction{
-	"allow": rspec.ActAllow,
-	"errno": rspec.ActErrno,
-	"kill":  rspec.ActKill,
-	"trace": rspec.ActTrace,
-	"trap":  rspec.ActTrap,
+	"allow":        rspec.ActAllow,
+	"errno":        rspec.ActErrno,
+	"kill":         rspec.ActKill,
+	"kill_process": rspec.ActKillProcess,
+	"kill_thread":  rspec.ActKillThread,
+	"log":          rspec.ActLog,
+	"notify":       ActNotify,
+	"trace":        rspec.ActTrace,
+	"trap":         rspec.ActTrap,
 }
 
-// Take passed action, return the SCMP_ACT_<ACTION> version of it
+// Take passed action, either by its short name or as SCMP_ACT_<ACTION>,
+// return the SCMP_ACT_<ACTION> version of it
 func parseAction(action string) (rspec.LinuxSeccompAction, error) {
-	a, ok := actions[action]
-	if !ok {
-		return "", fmt.Errorf("unrecognized action: %s", action)
+	if a, ok := actions[action]; ok {
+		return a, nil
+	}
+	var valid []string
+	for name, a := range actions {
+		if action == string(a) {
+			return a, nil
+		}
+		valid = append(valid, name)
 	}
-	return a, nil
+	sort.Strings(valid)
+	return "", fmt.Errorf("unrecognized action: %s, must be one of %s", action, strings.Join(valid, ", "))
 }
 
 // ParseDefaultAction sets the default action of the seccomp configuration
@@ -26,6 +26,27 @@ func ParseArchitectureFlag(architectureArg string, config *rspec.LinuxSeccomp) e
 	return nil
 }
 
+// RemoveArchitecture removes an architecture, given either by its short name
+// or as SCMP_ARCH_*, from the Seccomp config
+func RemoveArchitecture(architectureArg string, config *rspec.LinuxSeccomp) error {
+	if config == nil {
+		return fmt.Errorf("Cannot remove architecture from nil Seccomp pointer")
+	}
+
+	correctedArch, err := parseArch(architectureArg)
+	if err != nil {
+		return err
+	}
+
+	for i, arch := range config.Architectures {
+		if arch == correctedArch {
+			config.Architectures = append(config.Architectures[:i], config.Architectures[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func parseArch(arch string) (rspec.Arch, error) {
 	arches := map[string]rspec.Arch{
 		"x86":         rspec.ArchX86,
@@ -47,9 +68,14 @@ func parseArch(arch string) (rspec.Arch, error) {
 		"s390":        rspec.ArchS390,
 		"s390x":       rspec.ArchS390X,
 	}
-	a, ok := arches[arch]
-	if !ok {
-		return "", fmt.Errorf("unrecognized architecture: %s", arch)
+	if a, ok := arches[arch]; ok {
+		return a, nil
+	}
+	// accept the SCMP_ARCH_* names used in the configuration as well
+	for _, a := range arches {
+		if arch == string(a) {
+			return a, nil
+		}
 	}
-	return a, nil
+	return "", fmt.Errorf("unrecognized architecture: %s", arch)
 }
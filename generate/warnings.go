@@ -0,0 +1,122 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pathDepth returns the number of elements of the cleaned path.
+func pathDepth(path string) int {
+	path = filepath.Clean(path)
+	if path == "/" {
+		return 0
+	}
+	return strings.Count(path, "/")
+}
+
+// isSubpath returns true if path is below parent.
+func isSubpath(path, parent string) bool {
+	path, parent = filepath.Clean(path), filepath.Clean(parent)
+	if parent == "/" {
+		return path != "/"
+	}
+	return strings.HasPrefix(path, parent+"/")
+}
+
+// SortMounts reorders g.Config.Mounts so that every mount comes after the
+// mounts on its parent directories, by sorting them by the depth of their
+// destination.  Mounts of the same depth keep their order.
+func (g *Generator) SortMounts() {
+	if g.Config == nil {
+		return
+	}
+	sort.SliceStable(g.Config.Mounts, func(i, j int) bool {
+		return pathDepth(g.Config.Mounts[i].Destination) < pathDepth(g.Config.Mounts[j].Destination)
+	})
+}
+
+// isReleasedVersion returns true if version is one of SpecVersions.
+func isReleasedVersion(version string) bool {
+	for _, v := range SpecVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// newerFields returns the fields of g.Config which were only added to the
+// specification after the version it targets, if that is a released
+// version.
+func (g *Generator) newerFields() []string {
+	if !isReleasedVersion(g.Config.Version) {
+		return nil
+	}
+
+	// all the released versions predate these fields, which are in
+	// rspec.Version
+	var fields []string
+	if g.Config.Process != nil && g.Config.Process.CommandLine != "" {
+		fields = append(fields, "process.commandLine")
+	}
+	if g.Config.Linux != nil && g.Config.Linux.Resources != nil && len(g.Config.Linux.Resources.Unified) > 0 {
+		fields = append(fields, "linux.resources.unified")
+	}
+	return fields
+}
+
+// checkProcess returns an error if g.Config.Process has both args and a
+// command line on a platform other than windows, which is the only one to
+// run process.commandLine in place of process.args.
+func (g *Generator) checkProcess() error {
+	if g.Config == nil || g.Config.Windows != nil {
+		return nil
+	}
+	if process := g.Config.Process; process != nil && process.CommandLine != "" && len(process.Args) > 0 {
+		return fmt.Errorf("process.args and process.commandLine are both set, process.commandLine is only supported on windows")
+	}
+	return nil
+}
+
+// Validate returns warnings about settings of g.Config which are valid on
+// their own but conflict with each other.  It does not change g.Config,
+// and it does not check the configuration against the specification,
+// which is done by the validate package.
+func (g *Generator) Validate() []string {
+	var warnings []string
+	if g.Config == nil {
+		return warnings
+	}
+
+	if g.Config.Process != nil && g.Config.Process.OOMScoreAdj != nil &&
+		g.Config.Linux != nil && g.Config.Linux.Resources != nil && g.Config.Linux.Resources.Memory != nil &&
+		g.Config.Linux.Resources.Memory.DisableOOMKiller != nil && *g.Config.Linux.Resources.Memory.DisableOOMKiller {
+		warnings = append(warnings, "process.oomScoreAdj has no effect when the container runs out of memory, since linux.resources.memory.disableOOMKiller is set")
+	}
+
+	if g.Config.Windows != nil && g.Config.Process != nil && g.Config.Process.CommandLine != "" && len(g.Config.Process.Args) > 0 {
+		warnings = append(warnings, "process.args and process.commandLine are both set, process.args is ignored on windows")
+	}
+
+	for _, field := range g.newerFields() {
+		warnings = append(warnings, fmt.Sprintf("%s is not part of version %s of the specification, runtimes of that version ignore it", field, g.Config.Version))
+	}
+
+	for _, mount := range g.Config.Mounts {
+		if isBindMount(mount) && !filepath.IsAbs(mount.Source) {
+			warnings = append(warnings, fmt.Sprintf("bind mount on %s has the relative source %q, which is taken to be relative to the bundle", mount.Destination, mount.Source))
+		}
+	}
+
+	for i, mount := range g.Config.Mounts {
+		for _, later := range g.Config.Mounts[i+1:] {
+			if isSubpath(mount.Destination, later.Destination) {
+				warnings = append(warnings, fmt.Sprintf("mount on %s is covered by the later mount on %s, use SortMounts to mount parents first", mount.Destination, later.Destination))
+			}
+		}
+	}
+
+	return warnings
+}
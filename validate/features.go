@@ -0,0 +1,236 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/hashicorp/go-multierror"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Features is the output of a runtime's 'features' command, describing
+// which parts of the configuration the runtime supports.
+// https://github.com/opencontainers/runtime-spec/blob/main/features.md
+type Features struct {
+	OCIVersionMin string            `json:"ociVersionMin,omitempty"`
+	OCIVersionMax string            `json:"ociVersionMax,omitempty"`
+	Hooks         []string          `json:"hooks,omitempty"`
+	MountOptions  []string          `json:"mountOptions,omitempty"`
+	Linux         *LinuxFeatures    `json:"linux,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// LinuxFeatures describes the Linux specific features of a runtime.
+type LinuxFeatures struct {
+	Namespaces   []string         `json:"namespaces,omitempty"`
+	Capabilities []string         `json:"capabilities,omitempty"`
+	Cgroup       *CgroupFeatures  `json:"cgroup,omitempty"`
+	Seccomp      *SeccompFeatures `json:"seccomp,omitempty"`
+	Apparmor     *EnabledFeature  `json:"apparmor,omitempty"`
+	Selinux      *EnabledFeature  `json:"selinux,omitempty"`
+}
+
+// CgroupFeatures describes the cgroup support of a runtime.
+type CgroupFeatures struct {
+	V1      *bool `json:"v1,omitempty"`
+	V2      *bool `json:"v2,omitempty"`
+	Systemd *bool `json:"systemd,omitempty"`
+}
+
+// SeccompFeatures describes the seccomp support of a runtime.
+type SeccompFeatures struct {
+	Enabled   *bool    `json:"enabled,omitempty"`
+	Actions   []string `json:"actions,omitempty"`
+	Operators []string `json:"operators,omitempty"`
+	Archs     []string `json:"archs,omitempty"`
+}
+
+// EnabledFeature describes a feature which is either enabled or not.
+type EnabledFeature struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// LoadFeatures reads the features of a runtime from a JSON file.
+func LoadFeatures(path string) (*Features, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var features Features
+	if err := json.NewDecoder(f).Decode(&features); err != nil {
+		return nil, fmt.Errorf("invalid features JSON %s: %v", path, err)
+	}
+	return &features, nil
+}
+
+// disabled returns true if a feature is known to be disabled.  A feature
+// which is not reported is unknown, and treated as supported.
+func disabled(enabled *bool) bool {
+	return enabled != nil && !*enabled
+}
+
+// supported returns true if value is in the list of supported values.  An
+// empty list means the runtime does not report the feature, so everything
+// is treated as supported.
+func supported(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// systemdCgroupsPath returns true if path is in the slice:prefix:name form
+// of the systemd cgroup driver, rather than a path in the cgroup
+// filesystem.
+func systemdCgroupsPath(path string) bool {
+	return path != "" && !strings.HasPrefix(path, "/") && strings.Count(path, ":") == 2
+}
+
+// CheckFeatures checks the configuration against the features of a
+// runtime and returns an error for each configured field the runtime does
+// not support.
+func (v *Validator) CheckFeatures(features *Features) (errs error) {
+	if features == nil {
+		return nil
+	}
+
+	if features.OCIVersionMin != "" && v.spec.Version != "" {
+		minVersion, err := semver.Parse(features.OCIVersionMin)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("invalid ociVersionMin %q: %v", features.OCIVersionMin, err))
+		} else if version, err := semver.Parse(v.spec.Version); err == nil && version.LT(minVersion) {
+			errs = multierror.Append(errs, fmt.Errorf("ociVersion %s is older than the supported %s", v.spec.Version, features.OCIVersionMin))
+		}
+	}
+
+	if features.OCIVersionMax != "" && v.spec.Version != "" {
+		maxVersion, err := semver.Parse(features.OCIVersionMax)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("invalid ociVersionMax %q: %v", features.OCIVersionMax, err))
+		} else if version, err := semver.Parse(v.spec.Version); err == nil && version.GT(maxVersion) {
+			errs = multierror.Append(errs, fmt.Errorf("ociVersion %s is newer than the supported %s", v.spec.Version, features.OCIVersionMax))
+		}
+	}
+
+	if v.spec.Hooks != nil {
+		hooks := []struct {
+			name  string
+			hooks []rspec.Hook
+		}{
+			{"prestart", v.spec.Hooks.Prestart},
+			{"createRuntime", v.spec.Hooks.CreateRuntime},
+			{"createContainer", v.spec.Hooks.CreateContainer},
+			{"startContainer", v.spec.Hooks.StartContainer},
+			{"poststart", v.spec.Hooks.Poststart},
+			{"poststop", v.spec.Hooks.Poststop},
+		}
+		for _, h := range hooks {
+			if len(h.hooks) > 0 && !supported(features.Hooks, h.name) {
+				errs = multierror.Append(errs, fmt.Errorf("%s hooks are not supported by the runtime", h.name))
+			}
+		}
+	}
+
+	for _, mount := range v.spec.Mounts {
+		for _, option := range mount.Options {
+			// options with a value are passed to the filesystem
+			if strings.Contains(option, "=") {
+				continue
+			}
+			if !supported(features.MountOptions, option) {
+				errs = multierror.Append(errs, fmt.Errorf("mount option %q of %s is not supported by the runtime", option, mount.Destination))
+			}
+		}
+	}
+
+	if features.Linux != nil {
+		if err := v.checkLinuxFeatures(features.Linux); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	return
+}
+
+func (v *Validator) checkLinuxFeatures(features *LinuxFeatures) (errs error) {
+	if v.spec.Process != nil {
+		if caps := v.spec.Process.Capabilities; caps != nil {
+			checked := map[string]bool{}
+			for _, capList := range [][]string{caps.Bounding, caps.Effective, caps.Inheritable, caps.Permitted, caps.Ambient} {
+				for _, capability := range capList {
+					if checked[capability] {
+						continue
+					}
+					checked[capability] = true
+					if !supported(features.Capabilities, capability) {
+						errs = multierror.Append(errs, fmt.Errorf("capability %s is not supported by the runtime", capability))
+					}
+				}
+			}
+		}
+		if v.spec.Process.ApparmorProfile != "" && features.Apparmor != nil && disabled(features.Apparmor.Enabled) {
+			errs = multierror.Append(errs, fmt.Errorf("apparmor is not supported by the runtime"))
+		}
+		if v.spec.Process.SelinuxLabel != "" && features.Selinux != nil && disabled(features.Selinux.Enabled) {
+			errs = multierror.Append(errs, fmt.Errorf("selinux is not supported by the runtime"))
+		}
+	}
+
+	if v.spec.Linux == nil {
+		return
+	}
+
+	for _, ns := range v.spec.Linux.Namespaces {
+		if !supported(features.Namespaces, string(ns.Type)) {
+			errs = multierror.Append(errs, fmt.Errorf("namespace %s is not supported by the runtime", ns.Type))
+		}
+	}
+
+	if cgroup := features.Cgroup; cgroup != nil && (v.spec.Linux.Resources != nil || v.spec.Linux.CgroupsPath != "") {
+		if disabled(cgroup.V1) && disabled(cgroup.V2) {
+			errs = multierror.Append(errs, fmt.Errorf("cgroups are not supported by the runtime"))
+		} else if v.spec.Linux.Resources != nil && len(v.spec.Linux.Resources.Unified) > 0 && disabled(cgroup.V2) {
+			errs = multierror.Append(errs, fmt.Errorf("linux.resources.unified needs cgroup v2, which is not supported by the runtime"))
+		}
+		if systemdCgroupsPath(v.spec.Linux.CgroupsPath) && disabled(cgroup.Systemd) {
+			errs = multierror.Append(errs, fmt.Errorf("cgroupsPath %q is for the systemd cgroup driver, which is not supported by the runtime", v.spec.Linux.CgroupsPath))
+		}
+	}
+
+	if seccomp := v.spec.Linux.Seccomp; seccomp != nil && features.Seccomp != nil {
+		if disabled(features.Seccomp.Enabled) {
+			errs = multierror.Append(errs, fmt.Errorf("seccomp is not supported by the runtime"))
+			return
+		}
+		if !supported(features.Seccomp.Actions, string(seccomp.DefaultAction)) {
+			errs = multierror.Append(errs, fmt.Errorf("seccomp action %s is not supported by the runtime", seccomp.DefaultAction))
+		}
+		for _, arch := range seccomp.Architectures {
+			if !supported(features.Seccomp.Archs, string(arch)) {
+				errs = multierror.Append(errs, fmt.Errorf("seccomp architecture %s is not supported by the runtime", arch))
+			}
+		}
+		for _, syscall := range seccomp.Syscalls {
+			if !supported(features.Seccomp.Actions, string(syscall.Action)) {
+				errs = multierror.Append(errs, fmt.Errorf("seccomp action %s is not supported by the runtime", syscall.Action))
+			}
+			for _, arg := range syscall.Args {
+				if !supported(features.Seccomp.Operators, string(arg.Op)) {
+					errs = multierror.Append(errs, fmt.Errorf("seccomp operator %s is not supported by the runtime", arg.Op))
+				}
+			}
+		}
+	}
+
+	return
+}
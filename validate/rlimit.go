@@ -1,8 +1,8 @@
-package main
+package validate
 
 import "fmt"
 
-// These values map to rlimit constants defined in linux
+// These values map to the rlimit constants defined in linux.
 const (
 	RlimitCPU        = iota // CPU time in sec
 	RlimitFsize             // Maximum filesize
@@ -23,28 +23,30 @@ const (
 )
 
 var rlimitMap = map[string]int{
-	"RLIMIT_CPU":       RlimitCPU,
-	"RLIMIT_FSIZE":     RlimitFsize,
-	"RLIMIT_DATA":      RlimitData,
-	"RLIMIT_STACK":     RlimitStack,
-	"RLIMIT_CORE":      RlimitCore,
-	"RLIMIT_RSS":       RlimitRss,
-	"RLIMIT_NPROC":     RlimitNproc,
-	"RLIMIT_NOFILE":    RlimitNofile,
-	"RLIMIT_MEMLOCK":   RlimitMemlock,
-	"RLIMIT_AS":        RlimitAs,
-	"RLIMIT_LOCKS":     RlimitLocks,
-	"RLIMIT_SGPENDING": RlimitSigpending,
-	"RLIMIT_MSGQUEUE":  RlimitMsgqueue,
-	"RLIMIT_NICE":      RlimitNice,
-	"RLIMIT_RTPRIO":    RlimitRtprio,
-	"RLIMIT_RTTIME":    RlimitRttime,
+	"RLIMIT_CPU":        RlimitCPU,
+	"RLIMIT_FSIZE":      RlimitFsize,
+	"RLIMIT_DATA":       RlimitData,
+	"RLIMIT_STACK":      RlimitStack,
+	"RLIMIT_CORE":       RlimitCore,
+	"RLIMIT_RSS":        RlimitRss,
+	"RLIMIT_NPROC":      RlimitNproc,
+	"RLIMIT_NOFILE":     RlimitNofile,
+	"RLIMIT_MEMLOCK":    RlimitMemlock,
+	"RLIMIT_AS":         RlimitAs,
+	"RLIMIT_LOCKS":      RlimitLocks,
+	"RLIMIT_SIGPENDING": RlimitSigpending,
+	"RLIMIT_MSGQUEUE":   RlimitMsgqueue,
+	"RLIMIT_NICE":       RlimitNice,
+	"RLIMIT_RTPRIO":     RlimitRtprio,
+	"RLIMIT_RTTIME":     RlimitRttime,
 }
 
-func strToRlimit(key string) (int, error) {
-	rl, ok := rlimitMap[key]
+// RlimitFromString returns the linux resource number of the rlimit type
+// name, like RLIMIT_NOFILE.
+func RlimitFromString(name string) (int, error) {
+	rl, ok := rlimitMap[name]
 	if !ok {
-		return 0, fmt.Errorf("Wrong rlimit value: %s", key)
+		return 0, fmt.Errorf("wrong rlimit value: %s", name)
 	}
 	return rl, nil
 }
@@ -41,18 +41,6 @@ var (
 		"RLIMIT_STACK",
 	}
 
-	// https://git.kernel.org/pub/scm/docs/man-pages/man-pages.git/tree/man2/getrlimit.2?h=man-pages-4.13
-	linuxRlimits = append(posixRlimits, []string{
-		"RLIMIT_MEMLOCK",
-		"RLIMIT_MSGQUEUE",
-		"RLIMIT_NICE",
-		"RLIMIT_NPROC",
-		"RLIMIT_RSS",
-		"RLIMIT_RTPRIO",
-		"RLIMIT_RTTIME",
-		"RLIMIT_SIGPENDING",
-	}...)
-
 	configSchemaTemplate = "https://raw.githubusercontent.com/opencontainers/runtime-spec/v%s/schema/config-schema.json"
 )
 
@@ -339,11 +327,14 @@ func (v *Validator) CheckProcess() (errs error) {
 	}
 
 	if len(process.Args) == 0 {
-		errs = multierror.Append(errs,
-			specerror.NewError(
-				specerror.ProcArgsOneEntryRequired,
-				fmt.Errorf("args must not be empty"),
-				rspec.Version))
+		// on windows the command line may be given as a whole instead
+		if v.platform != "windows" || process.CommandLine == "" {
+			errs = multierror.Append(errs,
+				specerror.NewError(
+					specerror.ProcArgsOneEntryRequired,
+					fmt.Errorf("args must not be empty"),
+					rspec.Version))
+		}
 	} else {
 		if filepath.IsAbs(process.Args[0]) && v.spec.Root != nil {
 			var rootfsPath string
@@ -687,6 +678,26 @@ func (v *Validator) CheckAnnotations() (errs error) {
 	return
 }
 
+// CapabilityString returns the canonical name of a capability, like
+// CAP_CHOWN.
+func CapabilityString(cap capability.Cap) string {
+	return fmt.Sprintf("CAP_%s", strings.ToUpper(cap.String()))
+}
+
+// AllCapabilityStrings returns the canonical names of the capabilities up
+// to the last capability of the host, LastCap, in the order of
+// capability.List.
+func AllCapabilityStrings() []string {
+	caps := []string{}
+	last := LastCap()
+	for _, cap := range capability.List() {
+		if cap <= last {
+			caps = append(caps, CapabilityString(cap))
+		}
+	}
+	return caps
+}
+
 // CapValid checks whether a capability is valid
 func CapValid(c string, hostSpecific bool) error {
 	isValid := false
@@ -695,7 +706,7 @@ func CapValid(c string, hostSpecific bool) error {
 		return fmt.Errorf("capability %s must start with CAP_", c)
 	}
 	for _, cap := range capability.List() {
-		if c == fmt.Sprintf("CAP_%s", strings.ToUpper(cap.String())) {
+		if c == CapabilityString(cap) {
 			if hostSpecific && cap > LastCap() {
 				return fmt.Errorf("%s is not supported on the current host", c)
 			}
@@ -710,6 +721,37 @@ func CapValid(c string, hostSpecific bool) error {
 	return nil
 }
 
+// namespacedSysctls are the sysctls, other than those under a namespaced
+// prefix, which are isolated by a namespace.
+var namespacedSysctls = map[string]rspec.LinuxNamespaceType{
+	"kernel.domainname":      rspec.UTSNamespace,
+	"kernel.hostname":        rspec.UTSNamespace,
+	"kernel.msgmax":          rspec.IPCNamespace,
+	"kernel.msgmnb":          rspec.IPCNamespace,
+	"kernel.msgmni":          rspec.IPCNamespace,
+	"kernel.sem":             rspec.IPCNamespace,
+	"kernel.shm_rmid_forced": rspec.IPCNamespace,
+	"kernel.shmall":          rspec.IPCNamespace,
+	"kernel.shmmax":          rspec.IPCNamespace,
+	"kernel.shmmni":          rspec.IPCNamespace,
+}
+
+// SysctlNamespace returns the namespace which isolates the sysctl key.
+// The second return value is false if the sysctl is not namespaced, in
+// which case setting it affects the whole host.
+func SysctlNamespace(key string) (rspec.LinuxNamespaceType, bool) {
+	if ns, ok := namespacedSysctls[key]; ok {
+		return ns, true
+	}
+	switch {
+	case strings.HasPrefix(key, "net."):
+		return rspec.NetworkNamespace, true
+	case strings.HasPrefix(key, "fs.mqueue."):
+		return rspec.IPCNamespace, true
+	}
+	return "", false
+}
+
 func envValid(env string) bool {
 	items := strings.Split(env, "=")
 	if len(items) < 2 {
@@ -732,10 +774,8 @@ func (v *Validator) rlimitValid(rlimit rspec.POSIXRlimit) (errs error) {
 	}
 
 	if v.platform == "linux" {
-		for _, val := range linuxRlimits {
-			if val == rlimit.Type {
-				return
-			}
+		if _, err := RlimitFromString(rlimit.Type); err == nil {
+			return
 		}
 		errs = multierror.Append(errs, specerror.NewError(specerror.PosixProcRlimitsTypeValueError, fmt.Errorf("rlimit type %q may not be valid", rlimit.Type), v.spec.Version))
 	} else if v.platform == "solaris" {
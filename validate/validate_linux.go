@@ -91,13 +91,28 @@ func (v *Validator) CheckLinux() (errs error) {
 	}
 
 	for k := range v.spec.Linux.Sysctl {
-		if strings.HasPrefix(k, "net.") && !nsTypeList[rspec.NetworkNamespace].newExist {
-			errs = multierror.Append(errs, fmt.Errorf("sysctl %v requires a new Network namespace to be specified as well", k))
+		ns, namespaced := SysctlNamespace(k)
+		if !namespaced {
+			logrus.Warnf("sysctl %v is not namespaced, setting it affects the host", k)
+			continue
 		}
-		if strings.HasPrefix(k, "fs.mqueue.") {
+		switch {
+		case strings.HasPrefix(k, "fs.mqueue."):
 			if !nsTypeList[rspec.MountNamespace].newExist || !nsTypeList[rspec.IPCNamespace].newExist {
 				errs = multierror.Append(errs, fmt.Errorf("sysctl %v requires a new IPC namespace and Mount namespace to be specified as well", k))
 			}
+		case ns == rspec.NetworkNamespace:
+			if !nsTypeList[rspec.NetworkNamespace].newExist {
+				errs = multierror.Append(errs, fmt.Errorf("sysctl %v requires a new Network namespace to be specified as well", k))
+			}
+		case ns == rspec.IPCNamespace:
+			if !nsTypeList[rspec.IPCNamespace].newExist {
+				errs = multierror.Append(errs, fmt.Errorf("sysctl %v requires a new IPC namespace to be specified as well", k))
+			}
+		case ns == rspec.UTSNamespace:
+			if !nsTypeList[rspec.UTSNamespace].newExist {
+				errs = multierror.Append(errs, fmt.Errorf("sysctl %v requires a new UTS namespace to be specified as well", k))
+			}
 		}
 	}
 
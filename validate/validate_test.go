@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/gocapability/capability"
 
 	"github.com/opencontainers/runtime-tools/specerror"
 )
@@ -397,6 +398,27 @@ func TestCheckProcess(t *testing.T) {
 			platform: "linux",
 			expected: specerror.ProcArgsOneEntryRequired,
 		},
+		{
+			val: rspec.Spec{
+				Version: "1.0.0",
+				Process: &rspec.Process{
+					CommandLine: "cmd /c echo hello",
+					Cwd:         "c:\\foo",
+				},
+			},
+			platform: "windows",
+			expected: specerror.NonError,
+		},
+		{
+			val: rspec.Spec{
+				Version: "1.0.0",
+				Process: &rspec.Process{
+					Cwd: "c:\\foo",
+				},
+			},
+			platform: "windows",
+			expected: specerror.ProcArgsOneEntryRequired,
+		},
 		{
 			val: rspec.Spec{
 				Version: "1.0.0",
@@ -626,6 +648,26 @@ func TestCheckLinux(t *testing.T) {
 	}
 }
 
+func TestSysctlNamespace(t *testing.T) {
+	cases := []struct {
+		key        string
+		ns         rspec.LinuxNamespaceType
+		namespaced bool
+	}{
+		{"net.ipv4.ip_forward", rspec.NetworkNamespace, true},
+		{"fs.mqueue.msg_max", rspec.IPCNamespace, true},
+		{"kernel.shmmax", rspec.IPCNamespace, true},
+		{"kernel.hostname", rspec.UTSNamespace, true},
+		{"kernel.pid_max", "", false},
+		{"vm.swappiness", "", false},
+	}
+	for _, c := range cases {
+		ns, namespaced := SysctlNamespace(c.key)
+		assert.Equal(t, c.ns, ns, c.key)
+		assert.Equal(t, c.namespaced, namespaced, c.key)
+	}
+}
+
 func TestCheckPlatform(t *testing.T) {
 	cases := []struct {
 		val      rspec.Spec
@@ -803,3 +845,83 @@ func TestCheckAnnotations(t *testing.T) {
 		assert.Equal(t, c.expected, specerror.FindError(err, c.expected), fmt.Sprintf("failed CheckAnnotations: %v %d", err, c.expected))
 	}
 }
+
+func TestCheckFeatures(t *testing.T) {
+	disabled := false
+	features := &Features{
+		OCIVersionMin: "1.0.0",
+		OCIVersionMax: "1.0.2",
+		Hooks:         []string{"prestart", "createRuntime", "poststop"},
+		MountOptions:  []string{"nosuid", "ro"},
+		Linux: &LinuxFeatures{
+			Namespaces: []string{"pid", "mount"},
+			Cgroup:     &CgroupFeatures{V2: &disabled, Systemd: &disabled},
+			Seccomp:    &SeccompFeatures{Enabled: &disabled},
+		},
+	}
+	cases := []struct {
+		val      rspec.Spec
+		expected int
+	}{
+		{rspec.Spec{Version: "1.0.0"}, 0},
+		{rspec.Spec{Version: "1.1.0"}, 1},
+		{rspec.Spec{Version: "1.0.0-rc5"}, 1},
+		{rspec.Spec{Version: "1.0.0", Mounts: []rspec.Mount{{Destination: "/tmp", Options: []string{"nosuid", "size=1m", "noexec"}}}}, 1},
+		{rspec.Spec{Version: "1.0.0", Linux: &rspec.Linux{Namespaces: []rspec.LinuxNamespace{{Type: "pid"}, {Type: "network"}}}}, 1},
+		{rspec.Spec{Version: "1.0.0", Linux: &rspec.Linux{Seccomp: &rspec.LinuxSeccomp{DefaultAction: rspec.ActAllow}}}, 1},
+		{rspec.Spec{Version: "1.0.0", Hooks: &rspec.Hooks{Prestart: []rspec.Hook{{Path: "/bin/true"}}, CreateRuntime: []rspec.Hook{{Path: "/bin/true"}}}}, 0},
+		{rspec.Spec{Version: "1.0.0", Hooks: &rspec.Hooks{CreateContainer: []rspec.Hook{{Path: "/bin/true"}}, StartContainer: []rspec.Hook{{Path: "/bin/true"}}}}, 2},
+		{rspec.Spec{Version: "1.0.0", Linux: &rspec.Linux{CgroupsPath: "/runtime-tools/test", Resources: &rspec.LinuxResources{}}}, 0},
+		{rspec.Spec{Version: "1.0.0", Linux: &rspec.Linux{Resources: &rspec.LinuxResources{Unified: map[string]string{"memory.high": "1000000"}}}}, 1},
+		{rspec.Spec{Version: "1.0.0", Linux: &rspec.Linux{CgroupsPath: "system.slice:runtime-tools:test"}}, 1},
+	}
+	for _, c := range cases {
+		v, err := NewValidator(&c.val, ".", false, "linux")
+		if err != nil {
+			t.Errorf("unexpected NewValidator error: %+v", err)
+		}
+		err = v.CheckFeatures(features)
+		count := 0
+		if merr, ok := err.(*multierror.Error); ok {
+			count = len(merr.Errors)
+		}
+		assert.Equal(t, c.expected, count, fmt.Sprintf("failed CheckFeatures: %v", err))
+	}
+}
+func TestRlimitFromString(t *testing.T) {
+	for name, expected := range map[string]int{
+		"RLIMIT_CPU":        0,
+		"RLIMIT_CORE":       4,
+		"RLIMIT_NOFILE":     7,
+		"RLIMIT_AS":         9,
+		"RLIMIT_LOCKS":      10,
+		"RLIMIT_SIGPENDING": 11,
+		"RLIMIT_RTTIME":     15,
+	} {
+		rl, err := RlimitFromString(name)
+		assert.Nil(t, err, name)
+		assert.Equal(t, expected, rl, name)
+	}
+	assert.Len(t, rlimitMap, 16)
+
+	for _, name := range []string{"", "nofile", "RLIMIT_SGPENDING", "RLIMIT_BOGUS"} {
+		_, err := RlimitFromString(name)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestAllCapabilityStrings(t *testing.T) {
+	expected := []string{}
+	for _, cap := range capability.List() {
+		if cap <= LastCap() {
+			expected = append(expected, CapabilityString(cap))
+		}
+	}
+	caps := AllCapabilityStrings()
+	assert.Equal(t, expected, caps)
+
+	assert.Equal(t, "CAP_CHOWN", CapabilityString(capability.CAP_CHOWN))
+	for _, cap := range caps {
+		assert.Nil(t, CapValid(cap, true))
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tap "github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// annotations have values which a runtime could easily mangle: non-ASCII
+// text, characters JSON escapes, and a long value.
+var annotations = map[string]string{
+	"org.opencontainers.runtime-tools.plain":   "annotations test",
+	"org.opencontainers.runtime-tools.unicode": "Grüße, 世界 🐳",
+	"org.opencontainers.runtime-tools.escaped": "\"quoted\" <html> & \\ back\tslash\nnewline",
+	"org.opencontainers.runtime-tools.long":    strings.Repeat("0123456789abcdef", 4096),
+	"org.opencontainers.runtime-tools.empty":   "",
+}
+
+// changedAnnotations returns the keys of annotations which are missing or
+// have another value in actual.
+func changedAnnotations(actual map[string]string) []string {
+	var changed []string
+	for key, value := range annotations {
+		if v, ok := actual[key]; !ok || v != value {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func checkAnnotations(t *tap.T, actual map[string]string, source string) {
+	changed := changedAnnotations(actual)
+	t.Ok(len(changed) == 0, fmt.Sprintf("the annotations in %s are the configured ones", source))
+	if len(changed) > 0 {
+		t.YAML(map[string]interface{}{
+			"changed": changed,
+		})
+	}
+}
+
+// The runtime passes the annotations of the configuration unchanged to
+// the state and so to the hooks, which rely on them.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	for key, value := range annotations {
+		g.AddAnnotation(key, value)
+	}
+	rootfs := filepath.Join(bundleDir, g.Spec().Root.Path)
+	hookOutput := filepath.Join(rootfs, "prestart-state")
+	timeout := 1
+	g.AddPreStartHook(rspec.Hook{
+		Path:    filepath.Join(rootfs, "bin", "sh"),
+		Args:    []string{"sh", "-c", fmt.Sprintf("cat > %s", hookOutput)},
+		Timeout: &timeout,
+	})
+	g.SetProcessArgs([]string{"true"})
+
+	var state rspec.State
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PostCreate: func(r *util.Runtime) error {
+			state, err = r.State()
+			return err
+		},
+		PreDelete: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second)
+		},
+	})
+	if err != nil {
+		util.Fatal(err)
+	}
+	checkAnnotations(t, state.Annotations, "the state of the runtime")
+
+	data, err := ioutil.ReadFile(hookOutput)
+	if err != nil {
+		util.Fatal(err)
+	}
+	var hookState rspec.State
+	if err := json.Unmarshal(data, &hookState); err != nil {
+		util.Fatal(fmt.Errorf("invalid state in the stdin of the prestart hook: %v", err))
+	}
+	checkAnnotations(t, hookState.Annotations, "the state passed to the prestart hook")
+}
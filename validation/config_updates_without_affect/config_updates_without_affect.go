@@ -42,7 +42,9 @@ func main() {
 	g.AddLinuxReadonlyPaths("/proc/fs")
 	g.AddLinuxSysctl("net.ipv4.ip_forward", "1")
 	g.SetProcessOOMScoreAdj(100)
-	g.AddProcessRlimits("RLIMIT_NOFILE", 1024, 1024)
+	if err := g.AddProcessRlimits("RLIMIT_NOFILE", 1024, 1024); err != nil {
+		util.Fatal(err)
+	}
 	g.SetLinuxRootPropagation("shared")
 
 	err = r.SetConfig(g)
@@ -21,7 +21,9 @@ func main() {
 	if err != nil {
 		util.Fatal(err)
 	}
-	g.SetRootPath(".")
+	if err := g.SetRootPath("."); err != nil {
+		util.Fatal(err)
+	}
 	g.SetProcessArgs([]string{"ls"})
 
 	bundleDir, err := util.PrepareBundle()
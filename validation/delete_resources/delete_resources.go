@@ -39,7 +39,9 @@ func main() {
 	var limit int64 = 1000
 
 	g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
-	g.SetLinuxResourcesPidsLimit(limit)
+	if err := g.SetLinuxResourcesPidsLimit(limit); err != nil {
+		util.Fatal(err)
+	}
 
 	err = r.SetConfig(g)
 	if err != nil {
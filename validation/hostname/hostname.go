@@ -14,7 +14,9 @@ func testHostname(t *tap.T, hostname string) error {
 		return err
 	}
 
-	g.SetHostname(hostname)
+	if err := g.SetHostname(hostname); err != nil {
+		return err
+	}
 	g.AddAnnotation("TestName", fmt.Sprintf("check hostname %q", hostname))
 	err = util.RuntimeInsideValidate(g, t, nil)
 	t.Ok(err == nil, "hostname is set correctly")
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+	readBps    = 1048576
+)
+
+// loopDevice returns the device numbers of a loop device, which exists on
+// most test systems unlike a particular disk.
+func loopDevice() (major, minor int64, err error) {
+	devs, err := filepath.Glob("/sys/block/loop*/dev")
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(devs) == 0 {
+		return 0, 0, fmt.Errorf("no loop device found")
+	}
+	data, err := ioutil.ReadFile(devs[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(data)), "%d:%d", &major, &minor); err != nil {
+		return 0, 0, fmt.Errorf("invalid device number in %s: %v", devs[0], err)
+	}
+	return major, minor, nil
+}
+
+// isCgroup2 returns true if cgroupRoot is the unified cgroup v2 hierarchy.
+func isCgroup2() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// throttleFile returns the file holding the read bps throttle of the
+// cgroup at cgroupsPath, and whether it uses the cgroup v2 io.max format.
+func throttleFile(cgroupsPath string) (string, bool, error) {
+	path, err := cgroups.ResolveCgroupsPath(cgroupsPath)
+	if err != nil {
+		return "", false, err
+	}
+	if isCgroup2() {
+		return filepath.Join(cgroupRoot, path, "io.max"), true, nil
+	}
+	return filepath.Join(cgroupRoot, "blkio", path, "blkio.throttle.read_bps_device"), false, nil
+}
+
+// hasController returns true if controllers lists controller.
+func hasController(controllers []string, controller string) bool {
+	for _, c := range controllers {
+		if c == controller {
+			return true
+		}
+	}
+	return false
+}
+
+// readThrottle returns the read bps throttle of major:minor in a v1
+// blkio.throttle.read_bps_device file, with lines like "7:0 1048576", or
+// in a v2 io.max file, with lines like "7:0 rbps=1048576 wbps=max ...".
+func readThrottle(path string, v2 bool, major, minor int64) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	device := fmt.Sprintf("%d:%d", major, minor)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != device {
+			continue
+		}
+		if !v2 {
+			return fields[1], nil
+		}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "rbps=") {
+				return strings.TrimPrefix(field, "rbps="), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no throttle for %s in %s", device, path)
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific cgroup test")
+		return
+	}
+
+	major, minor, err := loopDevice()
+	if err != nil {
+		t.Skip(1, err.Error())
+		return
+	}
+	if isCgroup2() {
+		controllers, err := ioutil.ReadFile(filepath.Join(cgroupRoot, "cgroup.controllers"))
+		if err != nil || !hasController(strings.Fields(string(controllers)), "io") {
+			t.Skip(1, "the io controller is not available")
+			return
+		}
+	} else if _, err := os.Stat(filepath.Join(cgroupRoot, "blkio")); err != nil {
+		t.Skip(1, "the blkio controller is not available")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
+	g.AddLinuxResourcesBlockIOThrottleReadBpsDevice(major, minor, readBps)
+
+	err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+		path, v2, err := throttleFile(config.Linux.CgroupsPath)
+		if err != nil {
+			return err
+		}
+		rate, err := readThrottle(path, v2, major, minor)
+		t.Ok(err == nil && rate == fmt.Sprint(readBps), fmt.Sprintf("blkio read bps for %d:%d is set correctly", major, minor))
+		if err != nil {
+			t.Diagnostic(err.Error())
+		} else {
+			t.Diagnosticf("expect: %d, actual: %s", readBps, rate)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fail(err.Error())
+	}
+}
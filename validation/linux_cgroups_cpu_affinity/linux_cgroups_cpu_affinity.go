@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// allowedCPUs returns the Cpus_allowed_list value of a status file.
+func allowedCPUs(status []byte) (string, error) {
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "Cpus_allowed_list:" {
+			return fields[1], nil
+		}
+	}
+	return "", fmt.Errorf("no Cpus_allowed_list in %q", status)
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific cpuset test")
+		return
+	}
+
+	status, err := ioutil.ReadFile("/proc/self/status")
+	if err != nil {
+		util.Fatal(err)
+	}
+	hostList, err := allowedCPUs(status)
+	if err != nil {
+		util.Fatal(err)
+	}
+	hostCPUs, err := generate.ParseCPUList(hostList)
+	if err != nil {
+		util.Fatal(err)
+	}
+	if len(hostCPUs) < 2 {
+		t.Skip(1, "cpu affinity test requires more than one CPU")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	// pin to the last CPU, so it is not the CPU most processes start on
+	cpu := strconv.Itoa(hostCPUs[len(hostCPUs)-1])
+	if err := g.SetLinuxResourcesCPUCpus(cpu); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "cat /proc/self/status > /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	actual, err := allowedCPUs(output)
+	if err != nil {
+		util.Fatal(err)
+	}
+	t.Ok(actual == cpu, "the process may only run on the CPUs in linux.resources.cpu.cpus")
+	t.Diagnosticf("expect: %s, actual: %s", cpu, actual)
+}
@@ -72,11 +72,15 @@ func testCPUCgroups() error {
 		}
 
 		if c.cpus != "" {
-			g.SetLinuxResourcesCPUCpus(c.cpus)
+			if err := g.SetLinuxResourcesCPUCpus(c.cpus); err != nil {
+				util.Fatal(err)
+			}
 		}
 
 		if c.mems != "" {
-			g.SetLinuxResourcesCPUMems(c.mems)
+			if err := g.SetLinuxResourcesCPUMems(c.mems); err != nil {
+				util.Fatal(err)
+			}
 		}
 
 		// NOTE: On most systems where CONFIG_RT_GROUP & CONFIG_RT_GROUP_SCHED are not enabled,
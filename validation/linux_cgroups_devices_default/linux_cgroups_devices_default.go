@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// defaultDevices are the devices a runtime must supply, which the
+// container must be able to read and write although the default
+// configuration denies access to all devices in its device cgroup.
+var defaultDevices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom"}
+
+// unlistedDevice is a node of /dev/mem, which the container creates
+// itself and must not be able to access.
+const unlistedDevice = "/unlisted"
+
+// accessScript tries to read and open for writing each of the default
+// devices, and to create and read the unlisted device, and writes one
+// "<path> <r|w> <ok|fail>" line per attempt to /output.
+var accessScript = fmt.Sprintf(`for dev in %s; do
+	if head -c 1 $dev > /dev/null; then echo "$dev r ok"; else echo "$dev r fail"; fi
+	if : > $dev; then echo "$dev w ok"; else echo "$dev w fail"; fi
+done > /output
+if mknod %s c 1 1 && head -c 1 %s > /dev/null; then echo "%s r ok"; else echo "%s r fail"; fi >> /output`,
+	strings.Join(defaultDevices, " "), unlistedDevice, unlistedDevice, unlistedDevice, unlistedDevice)
+
+// The default configuration denies access to all devices in the device
+// cgroup, but the runtime must still allow the default devices.  Access to
+// a device which is neither a default device nor in the configuration is
+// denied.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific cgroup test")
+		return
+	}
+
+	// on cgroup v2 there is no devices controller, the runtime controls
+	// device access with an eBPF program attached to the cgroup instead,
+	// so only the cgroup v1 devices controller is checked.
+	if _, err := cgroups.GetSubsystemPath(os.Getpid(), "devices"); err != nil {
+		t.Skip(1, "the host has no cgroup v1 devices controller")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", accessScript})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		util.Fatal(err)
+	}
+	results := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 {
+			results[fields[0]+" "+fields[1]] = fields[2]
+		}
+	}
+
+	for _, dev := range defaultDevices {
+		for _, access := range []struct {
+			mode string
+			name string
+		}{
+			{"r", "read"},
+			{"w", "opened for writing"},
+		} {
+			result := results[dev+" "+access.mode]
+			t.Ok(result == "ok", fmt.Sprintf("%s can be %s", dev, access.name))
+			if result != "ok" {
+				t.YAML(map[string]string{
+					"device": dev,
+					"result": result,
+				})
+			}
+		}
+	}
+
+	result := results[unlistedDevice+" r"]
+	t.Ok(result == "fail", "an unlisted device cannot be created or read")
+	if result != "fail" {
+		t.YAML(map[string]string{
+			"device": "c 1:1",
+			"result": result,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
+	"github.com/opencontainers/runtime-tools/validation/util"
+	uuid "github.com/satori/go.uuid"
+)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
+	err = util.RuntimeOutsideValidate(g, t, util.ValidateLinuxCgroupsFreezer)
+	if err != nil {
+		t.Fail(err.Error())
+	}
+
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
+	g.SetProcessArgs([]string{"sleep", "30"})
+
+	checkFreezerState := func(r *util.Runtime, expected string) error {
+		state, err := r.State()
+		if err != nil {
+			return err
+		}
+		cg, err := cgroups.FindCgroup()
+		if err != nil {
+			return err
+		}
+		freezerState, err := cg.GetFreezerState(state.Pid, g.Config.Linux.CgroupsPath)
+		if err != nil {
+			return err
+		}
+		t.Ok(freezerState == expected, fmt.Sprintf("freezer state is %s", expected))
+		t.Diagnosticf("expect: %s, actual: %s", expected, freezerState)
+		return nil
+	}
+
+	config := util.LifecycleConfig{
+		Config:  g,
+		Actions: util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionPause | util.LifecycleActionResume | util.LifecycleActionDelete,
+		PreCreate: func(r *util.Runtime) error {
+			r.SetID(uuid.NewV4().String())
+			return nil
+		},
+		PostPause: func(r *util.Runtime) error {
+			return checkFreezerState(r, cgroups.FreezerStateFrozen)
+		},
+		PostResume: func(r *util.Runtime) error {
+			return checkFreezerState(r, cgroups.FreezerStateThawed)
+		},
+		PreDelete: func(r *util.Runtime) error {
+			if err := r.Kill("KILL"); err != nil {
+				return err
+			}
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second/10)
+		},
+	}
+	err = util.RuntimeLifecycleValidate(config)
+	if err != nil {
+		t.Fail(err.Error())
+	}
+}
@@ -32,8 +32,19 @@ func testHugetlbCgroups() error {
 			return err
 		}
 		g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
+		// the second limit replaces the first one
+		g.AddLinuxResourcesHugepageLimit(pageSize, limit/2)
 		g.AddLinuxResourcesHugepageLimit(pageSize, limit)
 		err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+			count := 0
+			for _, lhl := range config.Linux.Resources.HugepageLimits {
+				if lhl.Pagesize == pageSize {
+					count++
+				}
+			}
+			t.Ok(count == 1, fmt.Sprintf("config has a single hugepage limit for size: %s", pageSize))
+			t.Diagnosticf("expect: 1, actual: %d", count)
+
 			cg, err := cgroups.FindCgroup()
 			if err != nil {
 				return err
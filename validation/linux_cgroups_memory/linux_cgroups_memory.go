@@ -2,13 +2,130 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/cgroups"
+	"github.com/opencontainers/runtime-tools/cmd/runtimetest/mount"
 	"github.com/opencontainers/runtime-tools/validation/util"
 )
 
+// unifiedMountpoint returns the cgroup v2 mountpoint, or "" if the host
+// has no cgroup v2 mount.
+func unifiedMountpoint() (string, error) {
+	mounts, err := mount.GetMounts()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range mounts {
+		if m.Fstype == "cgroup2" {
+			return m.Mountpoint, nil
+		}
+	}
+	return "", nil
+}
+
+// unifiedCgroupPath returns the path of the cgroup v2 directory of a
+// process, or "" if the host has no cgroup v2 mount.
+func unifiedCgroupPath(pid int) (string, error) {
+	mountpoint, err := unifiedMountpoint()
+	if err != nil || mountpoint == "" {
+		return "", err
+	}
+
+	contents, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return filepath.Join(mountpoint, strings.TrimPrefix(line, "0::")), nil
+		}
+	}
+	return "", fmt.Errorf("process %d is not in a cgroup v2 hierarchy", pid)
+}
+
+// swapAccounting returns true if the host accounts swap usage in cgroups,
+// without which there is no swap limit to check.  On cgroup v2, the root
+// cgroup has no swap files, so any cgroup below it is looked at.
+func swapAccounting() (bool, error) {
+	cg, err := cgroups.FindCgroup()
+	if err == nil {
+		cgv1, ok := cg.(*cgroups.CgroupV1)
+		if !ok {
+			return false, nil
+		}
+		_, err := os.Stat(filepath.Join(cgv1.MountPath, "memory", "memory.memsw.limit_in_bytes"))
+		return err == nil, nil
+	}
+
+	mountpoint, err := unifiedMountpoint()
+	if err != nil || mountpoint == "" {
+		return false, err
+	}
+	files, err := filepath.Glob(filepath.Join(mountpoint, "*", "memory.swap.max"))
+	if err != nil {
+		return false, err
+	}
+	return len(files) > 0, nil
+}
+
+// validateMemorySwap checks the swap limit of the container.  In the
+// configuration, linux.resources.memory.swap is the limit of memory and
+// swap usage combined, not of the swap alone.  On cgroup v1 this is
+// exactly what memory.memsw.limit_in_bytes holds, so the two values are
+// equal.  On cgroup v2, memory.swap.max only limits the swap, so it holds
+// the swap limit minus the memory limit.  A swap of -1 means no limit in
+// both cases.
+func validateMemorySwap(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+	limit := *config.Linux.Resources.Memory.Limit
+	swap := *config.Linux.Resources.Memory.Swap
+
+	cg, err := cgroups.FindCgroup()
+	if err == nil {
+		lm, err := cg.GetMemoryData(state.Pid, config.Linux.CgroupsPath)
+		if err != nil {
+			return err
+		}
+		if swap == -1 {
+			// the kernel reports no limit as the largest multiple of the page size
+			t.Ok(*lm.Swap > math.MaxInt64-int64(os.Getpagesize()), "memory.memsw.limit_in_bytes is unlimited")
+		} else {
+			t.Ok(*lm.Swap == swap, "memory.memsw.limit_in_bytes is the memory+swap limit")
+		}
+		t.Diagnosticf("expect: %d, actual: %d", swap, *lm.Swap)
+		return nil
+	}
+
+	path, err := unifiedCgroupPath(state.Pid)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("no cgroup mount found")
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(path, "memory.swap.max"))
+	if err != nil {
+		return err
+	}
+
+	expected := "max"
+	if swap != -1 {
+		expected = strconv.FormatInt(swap-limit, 10)
+	}
+	actual := strings.TrimSpace(string(contents))
+	t.Ok(actual == expected, "memory.swap.max is the memory+swap limit minus the memory limit")
+	t.Diagnosticf("expect: %s, actual: %s", expected, actual)
+	return nil
+}
+
 func main() {
 	if "linux" != runtime.GOOS {
 		util.Fatal(fmt.Errorf("linux-specific cgroup test"))
@@ -48,5 +165,27 @@ func main() {
 		}
 	}
 
+	accounted, err := swapAccounting()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if !accounted {
+		t.Skip(1, "swap accounting is disabled on the host")
+	} else {
+		for _, swap := range []int64{50593792, 2 * 50593792, -1} {
+			g, err := util.GetDefaultGenerator()
+			if err != nil {
+				util.Fatal(err)
+			}
+			g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
+			g.SetLinuxResourcesMemoryLimit(50593792)
+			g.SetLinuxResourcesMemorySwap(swap)
+			err = util.RuntimeOutsideValidate(g, t, validateMemorySwap)
+			if err != nil {
+				t.Fail(err.Error())
+			}
+		}
+	}
+
 	t.AutoPlan()
 }
@@ -3,24 +3,27 @@ package main
 import (
 	"github.com/mndrix/tap-go"
 	"github.com/opencontainers/runtime-tools/cgroups"
+	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/opencontainers/runtime-tools/validation/util"
 )
 
 func main() {
-	var limit int64 = 1000
-
 	t := tap.New()
 	t.Header(0)
 	defer t.AutoPlan()
 
-	g, err := util.GetDefaultGenerator()
-	if err != nil {
-		util.Fatal(err)
-	}
-	g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
-	g.SetLinuxResourcesPidsLimit(limit)
-	err = util.RuntimeOutsideValidate(g, t, util.ValidateLinuxResourcesPids)
-	if err != nil {
-		t.Fail(err.Error())
+	for _, limit := range []int64{1000, generate.PidsUnlimited} {
+		g, err := util.GetDefaultGenerator()
+		if err != nil {
+			util.Fatal(err)
+		}
+		g.SetLinuxCgroupsPath(cgroups.AbsCgroupPath)
+		if err := g.SetLinuxResourcesPidsLimit(limit); err != nil {
+			util.Fatal(err)
+		}
+		err = util.RuntimeOutsideValidate(g, t, util.ValidateLinuxResourcesPids)
+		if err != nil {
+			t.Fail(err.Error())
+		}
 	}
 }
@@ -25,8 +25,12 @@ func main() {
 	g.SetLinuxResourcesCPUShares(shares)
 	g.SetLinuxResourcesCPUQuota(quota)
 	g.SetLinuxResourcesCPUPeriod(period)
-	g.SetLinuxResourcesCPUCpus(cpus)
-	g.SetLinuxResourcesCPUMems(mems)
+	if err := g.SetLinuxResourcesCPUCpus(cpus); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.SetLinuxResourcesCPUMems(mems); err != nil {
+		util.Fatal(err)
+	}
 	err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
 		cg, err := cgroups.FindCgroup()
 		t.Ok((err == nil), "find cpus cgroup")
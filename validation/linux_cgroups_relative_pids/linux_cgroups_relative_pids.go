@@ -18,7 +18,9 @@ func main() {
 		util.Fatal(err)
 	}
 	g.SetLinuxCgroupsPath(cgroups.RelCgroupPath)
-	g.SetLinuxResourcesPidsLimit(limit)
+	if err := g.SetLinuxResourcesPidsLimit(limit); err != nil {
+		util.Fatal(err)
+	}
 	err = util.RuntimeOutsideValidate(g, t, util.ValidateLinuxResourcesPids)
 	if err != nil {
 		t.Fail(err.Error())
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const cgroupV2Mount = "/sys/fs/cgroup"
+
+// unifiedCgroup returns the cgroup v2 path of the process pid.
+func unifiedCgroup(pid string) (string, error) {
+	f, err := os.Open(filepath.Join("/proc", pid, "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if strings.HasPrefix(s.Text(), "0::") {
+			return strings.TrimPrefix(s.Text(), "0::"), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("process %s is not in a cgroup v2 hierarchy", pid)
+}
+
+// owner returns the user ID owning path.
+func owner(path string) (uint32, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Sys().(*syscall.Stat_t).Uid, nil
+}
+
+// delegated returns nil if the cgroup of the current process is a cgroup
+// v2 owned by the current user, which can then create sub-cgroups.
+func delegated() error {
+	if _, err := os.Stat(filepath.Join(cgroupV2Mount, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("cgroup v2 is not mounted on %s", cgroupV2Mount)
+	}
+	cgroup, err := unifiedCgroup("self")
+	if err != nil {
+		return err
+	}
+	uid, err := owner(filepath.Join(cgroupV2Mount, cgroup))
+	if err != nil {
+		return err
+	}
+	if int(uid) != os.Geteuid() {
+		return fmt.Errorf("cgroup %s is not delegated to user %d", cgroup, os.Geteuid())
+	}
+	return nil
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific rootless cgroups test")
+		return
+	}
+	euid, egid := os.Geteuid(), os.Getegid()
+	if euid == 0 {
+		t.Skip(1, "rootless cgroups test requires an unprivileged user")
+		return
+	}
+	if err := delegated(); err != nil {
+		t.Skip(1, fmt.Sprintf("cgroup delegation is not configured: %v", err))
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("user", ""); err != nil {
+		util.Fatal(err)
+	}
+	g.AddLinuxUIDMapping(uint32(euid), 0, 1)
+	g.AddLinuxGIDMapping(uint32(egid), 0, 1)
+	g.SetLinuxCgroupsPath(cgroups.RelCgroupPath)
+	err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+		cgroup, err := unifiedCgroup(fmt.Sprintf("%d", state.Pid))
+		t.Ok(err == nil, "find the cgroup of the container process")
+		if err != nil {
+			t.Diagnostic(err.Error())
+			return nil
+		}
+
+		t.Ok(strings.HasSuffix(cgroup, config.Linux.CgroupsPath), "container process is in the relative cgroups path")
+		t.Diagnosticf("expect: %s, actual: %s", config.Linux.CgroupsPath, cgroup)
+
+		uid, err := owner(filepath.Join(cgroupV2Mount, cgroup))
+		t.Ok(err == nil && int(uid) == euid, "container cgroup is owned by the unprivileged user")
+		if err != nil {
+			t.Diagnostic(err.Error())
+		} else {
+			t.Diagnosticf("expect: %d, actual: %d", euid, uid)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fail(err.Error())
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+func main() {
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	// only the default devices and linux.devices may appear in /dev
+	g.AddAnnotation("org.opencontainers.runtimetest.devices.allowed", "")
+	err = util.RuntimeInsideValidate(g, nil, nil)
+	if err != nil {
+		util.Fatal(err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// fdSymlinks are the default symlinks to the file descriptors of the
+// process.
+var fdSymlinks = []struct {
+	path   string
+	target string
+}{
+	{"/dev/fd", "/proc/self/fd"},
+	{"/dev/stdin", "/proc/self/fd/0"},
+	{"/dev/stdout", "/proc/self/fd/1"},
+	{"/dev/stderr", "/proc/self/fd/2"},
+}
+
+// customDev replaces the /dev mount of g by a tmpfs with other options.
+// The runtime sets up the default symlinks on whatever is mounted on /dev,
+// so they must still exist.
+func customDev(g *generate.Generator) {
+	g.RemoveMount("/dev")
+	g.AddMount(rspec.Mount{
+		Destination: "/dev",
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     []string{"nosuid", "strictatime", "mode=700", "size=1m"},
+	})
+	g.SortMounts()
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific default symlinks test")
+		return
+	}
+
+	// runtimetest checks the default symlinks
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.AddAnnotation("TestName", "default symlinks")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	customDev(g)
+	g.AddAnnotation("TestName", "default symlinks with a custom /dev mount")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// a shell in the container reads the targets of the file descriptor
+	// symlinks
+	var script []string
+	for _, symlink := range fdSymlinks {
+		script = append(script, fmt.Sprintf("echo %s $(readlink %s)", symlink.path, symlink.path))
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "{ " + strings.Join(script, "; ") + "; } > /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	targets := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			targets[fields[0]] = fields[1]
+		}
+	}
+	for _, symlink := range fdSymlinks {
+		actual := targets[symlink.path]
+		t.Ok(actual == symlink.target, fmt.Sprintf("%s links to %s with a custom /dev mount", symlink.path, symlink.target))
+		if actual != symlink.target {
+			t.Diagnosticf("expect: %s, actual: %q", symlink.target, actual)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cmd/runtimetest/mount"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// parseSize parses the size option of a tmpfs, in bytes or with a k, m or
+// g suffix.  Sizes relative to the memory, with a % suffix, are not
+// supported.
+func parseSize(size string) (uint64, error) {
+	multiplier := uint64(1)
+	switch strings.ToLower(size[len(size)-1:]) {
+	case "k":
+		multiplier = 1 << 10
+	case "m":
+		multiplier = 1 << 20
+	case "g":
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		size = size[:len(size)-1]
+	}
+	value, err := strconv.ParseUint(size, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value * multiplier, nil
+}
+
+// sizeOption returns the value of the size option in a list of mount
+// options, or "" if there is none.
+func sizeOption(options []string) string {
+	size := ""
+	for _, option := range options {
+		if strings.HasPrefix(option, "size=") {
+			size = strings.TrimPrefix(option, "size=")
+		}
+	}
+	return size
+}
+
+// memTotal returns the total memory of the host in bytes.
+func memTotal() (uint64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" {
+			return parseSize(fields[1] + "k")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no MemTotal in /proc/meminfo")
+}
+
+// shmSize returns the size option of the /dev/shm mount of a process, or
+// "" if /dev/shm has no size limit.
+func shmSize(pid int) (string, error) {
+	mounts, err := mount.PidMountInfo(pid)
+	if err != nil {
+		return "", err
+	}
+	var shm *mount.Info
+	for _, m := range mounts {
+		if m.Mountpoint == "/dev/shm" {
+			shm = m
+		}
+	}
+	if shm == nil {
+		return "", fmt.Errorf("/dev/shm is not mounted in the container")
+	}
+	if shm.Fstype != "tmpfs" {
+		return "", fmt.Errorf("/dev/shm is a %s mount, not tmpfs", shm.Fstype)
+	}
+	return sizeOption(strings.Split(shm.VfsOpts, ",")), nil
+}
+
+// setShmOptions replaces the options of the /dev/shm mount of the
+// default configuration.
+func setShmOptions(config *rspec.Spec, options []string) {
+	for i := range config.Mounts {
+		if config.Mounts[i].Destination == "/dev/shm" {
+			config.Mounts[i].Options = options
+		}
+	}
+}
+
+// shmBytes returns the size of the /dev/shm mount of a process in bytes,
+// or 0 if /dev/shm has no size limit.
+func shmBytes(pid int) (uint64, error) {
+	size, err := shmSize(pid)
+	if err != nil || size == "" {
+		return 0, err
+	}
+	return parseSize(size)
+}
+
+// The runtime mounts /dev/shm with the size given in the configuration,
+// and the kernel default of a tmpfs, half of the memory, applies when no
+// size is given.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific /dev/shm test")
+		return
+	}
+
+	for _, size := range []string{"65536k", "1m"} {
+		expected, err := parseSize(size)
+		if err != nil {
+			util.Fatal(err)
+		}
+		g, err := util.GetDefaultGenerator()
+		if err != nil {
+			util.Fatal(err)
+		}
+		setShmOptions(g.Spec(), []string{"nosuid", "noexec", "nodev", "mode=1777", "size=" + size})
+		err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+			actual, err := shmBytes(state.Pid)
+			if err != nil {
+				return err
+			}
+			t.Ok(actual == expected, fmt.Sprintf("/dev/shm honors size=%s", size))
+			if actual != expected {
+				t.YAML(map[string]interface{}{
+					"expected": expected,
+					"actual":   actual,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fail(err.Error())
+		}
+	}
+
+	total, err := memTotal()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	setShmOptions(g.Spec(), []string{"nosuid", "noexec", "nodev", "mode=1777"})
+	err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+		actual, err := shmBytes(state.Pid)
+		if err != nil {
+			return err
+		}
+		// an unlimited tmpfs lets the container use up the memory of the host
+		ok := actual > 0 && actual <= total
+		t.Ok(ok, "/dev/shm without a size option is limited to at most the host memory")
+		if !ok {
+			t.YAML(map[string]interface{}{
+				"memTotal": total,
+				"actual":   actual,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fail(err.Error())
+	}
+}
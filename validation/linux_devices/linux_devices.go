@@ -25,7 +25,9 @@ func main() {
 	cdev.UID = &cuid
 	cgid := uint32(0)
 	cdev.GID = &cgid
-	g.AddDevice(cdev)
+	if err := g.AddDevice(cdev); err != nil {
+		util.Fatal(err)
+	}
 
 	// add block device
 	bdev := rspecs.LinuxDevice{}
@@ -39,7 +41,9 @@ func main() {
 	bdev.UID = &uid
 	gid := uint32(0)
 	bdev.GID = &gid
-	g.AddDevice(bdev)
+	if err := g.AddDevice(bdev); err != nil {
+		util.Fatal(err)
+	}
 
 	// add fifo device
 	pdev := rspecs.LinuxDevice{}
@@ -49,7 +53,9 @@ func main() {
 	pdev.Minor = 666
 	pmode := os.FileMode(int32(432))
 	pdev.FileMode = &pmode
-	g.AddDevice(pdev)
+	if err := g.AddDevice(pdev); err != nil {
+		util.Fatal(err)
+	}
 
 	err = util.RuntimeInsideValidate(g, nil, nil)
 	if err != nil {
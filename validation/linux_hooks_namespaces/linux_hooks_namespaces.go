@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// The vendored runtime-spec predates the createRuntime and createContainer
+// hooks, so this checks the hooks it knows about.  Those all run in the
+// runtime namespace.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific hooks namespace test")
+		return
+	}
+
+	runtimeNS, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	rootfs := filepath.Join(bundleDir, g.Spec().Root.Path)
+
+	// each hook records the mount namespace it observes in a file of the
+	// bundle, outside of the container
+	hook := func(name string) rspec.Hook {
+		return rspec.Hook{
+			Path: filepath.Join(rootfs, "bin/sh"),
+			Args: []string{"sh", "-c", fmt.Sprintf("%s /proc/self/ns/mnt > %s",
+				filepath.Join(rootfs, "bin/readlink"), filepath.Join(bundleDir, name))},
+		}
+	}
+	if err := g.AddPreStartHook(hook("prestart")); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddPostStartHook(hook("poststart")); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "readlink /proc/self/ns/mnt > /output"})
+
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	readNS := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Diagnostic(err.Error())
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	containerNS := readNS(filepath.Join(rootfs, "output"))
+	t.Ok(containerNS != "" && containerNS != runtimeNS, "the container process runs in a new mount namespace")
+
+	for _, name := range []string{"prestart", "poststart"} {
+		hookNS := readNS(filepath.Join(bundleDir, name))
+		t.Ok(hookNS == runtimeNS, fmt.Sprintf("%s hooks run in the runtime mount namespace", name))
+		if hookNS != runtimeNS {
+			t.Diagnosticf("expect: %s, actual: %s, container: %s", runtimeNS, hookNS, containerNS)
+		}
+	}
+}
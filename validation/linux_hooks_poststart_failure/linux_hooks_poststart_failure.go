@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/specerror"
+	"github.com/opencontainers/runtime-tools/validation/util"
+	uuid "github.com/satori/go.uuid"
+)
+
+// A failing poststart hook must not fail the container, while a failing
+// prestart hook must abort it.  The vendored runtime-spec predates the
+// createRuntime hooks, which fail like prestart ones.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific hook failure test")
+		return
+	}
+
+	// a failing poststart hook is only warned about: the next poststart
+	// hook still runs, start succeeds and the container process runs to
+	// completion
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	rootfs := filepath.Join(bundleDir, g.Spec().Root.Path)
+	marker := filepath.Join(bundleDir, "poststart")
+	if err := g.AddPostStartHook(rspec.Hook{
+		Path: filepath.Join(rootfs, "bin/false"),
+		Args: []string{"false"},
+	}); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddPostStartHook(rspec.Hook{
+		Path: filepath.Join(rootfs, "bin/sh"),
+		Args: []string{"sh", "-c", fmt.Sprintf("echo called > %s", marker)},
+	}); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "echo done > /output"})
+
+	runErr := util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	_, markerErr := os.Stat(marker)
+	output, _ := ioutil.ReadFile(filepath.Join(rootfs, "output"))
+
+	detailedErr := runErr
+	if detailedErr == nil && markerErr != nil {
+		detailedErr = errors.New("the poststart hook after the failing one was not called")
+	}
+	if detailedErr == nil && strings.TrimSpace(string(output)) != "done" {
+		detailedErr = errors.New("the container process did not run to completion")
+	}
+	util.SpecErrorOK(t, detailedErr == nil, specerror.NewError(specerror.PoststartHookFailGenWarn, fmt.Errorf("if any poststart hook fails, the runtime MUST log a warning, but the remaining hooks and lifecycle continue as if the hook had succeeded"), rspec.Version), detailedErr)
+
+	// a failing prestart hook makes the runtime generate an error without
+	// running the container process
+	prestartBundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(prestartBundleDir)
+
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	rootfs = filepath.Join(prestartBundleDir, g.Spec().Root.Path)
+	if err := g.AddPreStartHook(rspec.Hook{
+		Path: filepath.Join(rootfs, "bin/false"),
+		Args: []string{"false"},
+	}); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"touch", "/output"})
+
+	containerID := uuid.NewV4().String()
+	runErr = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: prestartBundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart,
+		PreCreate: func(r *util.Runtime) error {
+			r.SetID(containerID)
+			return nil
+		},
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	_, outputErr := os.Stat(filepath.Join(rootfs, "output"))
+
+	// delete whatever the runtime left behind
+	r, err := util.NewRuntime(util.RuntimeCommand, prestartBundleDir)
+	if err != nil {
+		util.Fatal(err)
+	}
+	r.SetID(containerID)
+	if _, err := r.State(); err == nil {
+		r.Clean(false, false)
+	}
+
+	detailedErr = nil
+	if runErr == nil {
+		detailedErr = errors.New("the runtime did not generate an error")
+	} else if outputErr == nil {
+		detailedErr = fmt.Errorf("the container process ran despite the error: %v", runErr)
+	}
+	util.SpecErrorOK(t, detailedErr == nil, specerror.NewError(specerror.PrestartHookFailGenError, fmt.Errorf("if any prestart hook fails, the runtime MUST generate an error, stop the container, and continue the lifecycle at step 9"), rspec.Version), detailedErr)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific masked paths test")
+		return
+	}
+
+	// runtimetest checks that masked procfs files are unreadable
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.Config.Linux.MaskedPaths = nil
+	g.AddLinuxMaskedPaths("/proc/kcore")
+	g.AddLinuxMaskedPaths("/proc/sched_debug")
+	g.AddAnnotation("TestName", "check masked procfs files")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// masking procfs files leaves the other procfs files readable
+	unmasked := "/proc/meminfo"
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.Config.Linux.MaskedPaths = nil
+	g.AddLinuxMaskedPaths("/proc/kcore")
+	g.SetProcessArgs([]string{"sh", "-c", fmt.Sprintf("head -c 1 %s > /output", unmasked)})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+	} else {
+		t.Ok(len(output) > 0, fmt.Sprintf("can read unmasked procfs file %q", unmasked))
+	}
+
+	// a masked procfs file is reported if it is not masked with /dev/null.
+	// The spec does not say how files are masked, so any other device is
+	// not a failure.
+	masked := "/proc/kcore"
+	g.SetProcessArgs([]string{"sh", "-c", fmt.Sprintf("stat -c %%t:%%T %s > /output", masked)})
+	output, err = util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+	device := strings.TrimSpace(string(output))
+	if device == "1:3" {
+		t.Pass(fmt.Sprintf("masked file %q is /dev/null", masked))
+	} else {
+		t.Skip(1, fmt.Sprintf("masked file %q is not /dev/null", masked))
+		t.YAML(map[string]string{
+			"path":   masked,
+			"device": device,
+		})
+	}
+}
@@ -9,7 +9,9 @@ func main() {
 	if err != nil {
 		util.Fatal(err)
 	}
-	g.SetLinuxMountLabel("system_u:object_r:svirt_sandbox_file_t:s0:c715,c811")
+	if err := g.SetLinuxMountLabel("system_u:object_r:svirt_sandbox_file_t:s0:c715,c811"); err != nil {
+		util.Fatal(err)
+	}
 	err = util.RuntimeInsideValidate(g, nil, nil)
 	if err != nil {
 		util.Fatal(err)
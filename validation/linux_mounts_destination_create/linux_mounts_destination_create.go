@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const (
+	dirDestination  = "/mnt/created/nested/dir"
+	fileDestination = "/created-file"
+	fileContent     = "destination-create"
+)
+
+// The runtime creates missing mount destinations, a directory for a
+// directory mount and a file for a file mount.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific mount destination test")
+		return
+	}
+
+	sourceDir, err := ioutil.TempDir("", "destination-create")
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(sourceDir)
+	dir := filepath.Join(sourceDir, "dir")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		util.Fatal(err)
+	}
+	file := filepath.Join(sourceDir, "file")
+	if err := ioutil.WriteFile(file, []byte(fileContent), 0644); err != nil {
+		util.Fatal(err)
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.AddMount(rspec.Mount{
+		Destination: dirDestination,
+		Type:        "bind",
+		Source:      dir,
+		Options:     []string{"bind"},
+	})
+	g.AddMount(rspec.Mount{
+		Destination: fileDestination,
+		Type:        "bind",
+		Source:      file,
+		Options:     []string{"bind"},
+	})
+	g.AddAnnotation("TestName", "mounts on missing destinations")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	g.SetProcessArgs([]string{"sh", "-c", fmt.Sprintf("if [ -d %s ]; then echo dir; fi > /output; if [ -f %s ]; then cat %s; fi >> /output",
+		dirDestination, fileDestination, fileDestination)})
+	existing := ""
+	output, err := util.RuntimeOutputValidate(g, func(bundleDir string) error {
+		for _, dest := range []string{dirDestination, fileDestination} {
+			if _, err := os.Stat(filepath.Join(bundleDir, g.Spec().Root.Path, dest)); err == nil {
+				existing = dest
+				return fmt.Errorf("%s already exists in the root filesystem", dest)
+			}
+		}
+		return nil
+	})
+	if existing != "" {
+		t.Skip(2, err.Error())
+		return
+	}
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	lines := strings.Fields(string(output))
+	t.Ok(len(lines) > 0 && lines[0] == "dir", fmt.Sprintf("missing destination %s is created as a directory", dirDestination))
+	t.Ok(len(lines) > 0 && lines[len(lines)-1] == fileContent, fmt.Sprintf("missing destination %s is created as a file", fileDestination))
+	if len(lines) == 0 {
+		t.Diagnostic("the container wrote no output")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const (
+	parentDestination = "/mnt/order"
+	childDestination  = "/mnt/order/child"
+	marker            = "mounts-order"
+)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific mounts order test")
+		return
+	}
+
+	childSource, err := ioutil.TempDir("", "mounts-order")
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(childSource)
+	if err := ioutil.WriteFile(filepath.Join(childSource, "marker"), []byte(marker), 0644); err != nil {
+		util.Fatal(err)
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	// the child is added first, SortMounts has to move it after the parent
+	g.AddMount(rspec.Mount{
+		Destination: childDestination,
+		Type:        "bind",
+		Source:      childSource,
+		Options:     []string{"bind"},
+	})
+	g.AddMount(rspec.Mount{
+		Destination: parentDestination,
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     []string{"nosuid", "nodev", "size=1m"},
+	})
+	t.Ok(len(g.Validate()) > 0, "a child mount before its parent mount is reported")
+	g.SortMounts()
+	t.Ok(len(g.Validate()) == 0, "sorted mounts mount parents first")
+
+	g.SetProcessArgs([]string{"sh", "-c", "cat " + filepath.Join(childDestination, "marker") + " > /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		util.Fatal(err)
+	}
+	t.Ok(strings.TrimSpace(string(output)) == marker, "a nested mount is not covered by its parent mount")
+	t.Diagnosticf("expect: %s, actual: %s", marker, strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// hidepidSupported returns true if the kernel is at least 3.3, which
+// added the hidepid option of proc.
+func hidepidSupported() bool {
+	release, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	var major, minor int
+	if _, err := fmt.Sscanf(string(release), "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	return major > 3 || (major == 3 && minor >= 3)
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific proc mount test")
+		return
+	}
+
+	if !hidepidSupported() {
+		t.Skip(1, "the kernel does not support hidepid")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.RemoveMount("/proc")
+	g.AddMount(rspec.Mount{
+		Destination: "/proc",
+		Type:        "proc",
+		Source:      "proc",
+		Options:     []string{"nosuid", "noexec", "nodev", "hidepid=2"},
+	})
+
+	// a process of root runs in the background, while a process of the
+	// daemon user looks for it and for itself in /proc
+	g.SetProcessArgs([]string{"sh", "-c", `sleep 10 & pid=$!
+chpst -u daemon:daemon sh -c "if [ -d /proc/$pid ]; then echo other; fi; if [ -d /proc/\$\$ ]; then echo self; fi" > /output
+kill $pid`})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		util.Fatal(err)
+	}
+	seen := strings.Fields(string(output))
+	t.Ok(len(seen) > 0 && seen[len(seen)-1] == "self", "a process sees itself in /proc with hidepid=2")
+	t.Ok(len(seen) == 0 || seen[0] != "other", "a process does not see the processes of other users in /proc with hidepid=2")
+	if len(seen) == 0 {
+		t.Diagnostic("the unprivileged process wrote no output")
+	}
+}
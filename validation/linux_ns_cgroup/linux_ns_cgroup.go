@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// In a new cgroup namespace the cgroups of the container process are the
+// roots of the namespace, so /proc/self/cgroup shows "/" for every
+// hierarchy instead of the path on the host.  This is independent of
+// where cgroupsPath places the container.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific cgroup namespace test")
+		return
+	}
+	if _, err := os.Stat("/proc/self/ns/cgroup"); err != nil {
+		t.Skip(1, "the kernel does not support cgroup namespaces")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("cgroup", ""); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "cat /proc/self/cgroup > /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	// each line is hierarchy-ID:controller-list:cgroup-path
+	paths := map[string]string{}
+	var outside []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		hierarchy := fields[1]
+		if hierarchy == "" {
+			hierarchy = "unified"
+		}
+		paths[hierarchy] = fields[2]
+		if fields[2] != "/" {
+			outside = append(outside, hierarchy)
+		}
+	}
+
+	if len(paths) == 0 {
+		t.Fail("no cgroups in /proc/self/cgroup of the container")
+		return
+	}
+	t.Ok(len(outside) == 0, "cgroup paths are relative to the cgroup namespace root")
+	t.YAML(map[string]interface{}{
+		"expected": "/",
+		"actual":   paths,
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// ipcObject is a System V IPC object created on the host with ipcmk.
+type ipcObject struct {
+	name    string   // name in the test output
+	option  string   // ipcmk option creating it, lowercase for ipcrm
+	sysvipc string   // file listing the objects in /proc/sysvipc
+	args    []string // further ipcmk arguments
+	key     string
+	id      string
+}
+
+// create creates the object with ipcmk and looks up its key.
+func (o *ipcObject) create() error {
+	args := append([]string{o.option}, o.args...)
+	out, err := exec.Command("ipcmk", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ipcmk %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	// ipcmk prints e.g. "Shared memory id: 42"
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return fmt.Errorf("unexpected ipcmk output %q", out)
+	}
+	o.id = fields[len(fields)-1]
+
+	data, err := ioutil.ReadFile(filepath.Join("/proc/sysvipc", o.sysvipc))
+	if err != nil {
+		return err
+	}
+	for _, entry := range sysvipcEntries(string(data)) {
+		if entry[1] == o.id {
+			o.key = entry[0]
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s is not in /proc/sysvipc/%s", o.name, o.id, o.sysvipc)
+}
+
+// remove removes the object with ipcrm.
+func (o *ipcObject) remove() {
+	if o.id != "" {
+		exec.Command("ipcrm", strings.ToLower(o.option), o.id).Run()
+	}
+}
+
+// sysvipcEntries returns the key and the id of the objects listed in
+// /proc/sysvipc files.  Header lines are returned too, but never match an
+// object.
+func sysvipcEntries(data string) [][2]string {
+	var entries [][2]string
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			entries = append(entries, [2]string{fields[0], fields[1]})
+		}
+	}
+	return entries
+}
+
+// A container in a new IPC namespace must not see the System V IPC
+// objects of the host.  A shared memory segment and a message queue are
+// created on the host and looked up by key and id in /proc/sysvipc of the
+// container.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific IPC namespace test")
+		return
+	}
+	if _, err := exec.LookPath("ipcmk"); err != nil {
+		t.Skip(1, "ipcmk is needed to create IPC objects on the host")
+		return
+	}
+
+	objects := []*ipcObject{
+		{name: "shared memory segment", option: "-M", sysvipc: "shm", args: []string{"4096"}},
+		{name: "message queue", option: "-Q", sysvipc: "msg"},
+	}
+	for _, o := range objects {
+		defer o.remove()
+		if err := o.create(); err != nil {
+			t.Fail(err.Error())
+			return
+		}
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("ipc", ""); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "cat /proc/sysvipc/shm /proc/sysvipc/msg > /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	entries := sysvipcEntries(string(output))
+	for _, o := range objects {
+		visible := false
+		for _, entry := range entries {
+			if entry[0] == o.key && entry[1] == o.id {
+				visible = true
+			}
+		}
+		t.Ok(!visible, fmt.Sprintf("host %s is not visible in the IPC namespace", o.name))
+		if visible {
+			t.YAML(map[string]string{
+				"key": o.key,
+				"id":  o.id,
+			})
+		}
+	}
+}
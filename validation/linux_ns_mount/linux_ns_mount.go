@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cmd/runtimetest/mount"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// sharedPrefixes are the trees the runtime populates with its own mounts,
+// which may have the same mount points on the host.
+var sharedPrefixes = []string{"/dev", "/proc", "/sys"}
+
+// hostOnlyMounts returns the mount points of the host which the container
+// is not configured to have.
+func hostOnlyMounts(config *rspec.Spec) ([]string, error) {
+	hostMounts, err := mount.GetMounts()
+	if err != nil {
+		return nil, err
+	}
+
+	configured := map[string]bool{"/": true}
+	for _, m := range config.Mounts {
+		configured[filepath.Clean(m.Destination)] = true
+	}
+
+	var mountpoints []string
+	for _, m := range hostMounts {
+		if configured[m.Mountpoint] {
+			continue
+		}
+		shared := false
+		for _, prefix := range sharedPrefixes {
+			if m.Mountpoint == prefix || strings.HasPrefix(m.Mountpoint, prefix+"/") {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			mountpoints = append(mountpoints, m.Mountpoint)
+		}
+	}
+	return mountpoints, nil
+}
+
+// The mount table of a container in a new mount namespace holds the
+// mounts of its configuration and the ones the runtime creates under
+// /dev, /proc and /sys, but none of the other mounts of the host, like
+// /home or /boot.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific mount namespace test")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("mount", ""); err != nil {
+		util.Fatal(err)
+	}
+
+	hostOnly, err := hostOnlyMounts(g.Spec())
+	if err != nil {
+		util.Fatal(err)
+	}
+	if len(hostOnly) == 0 {
+		t.Skip(1, "the host has no mounts outside of the container configuration")
+		return
+	}
+
+	err = util.RuntimeOutsideValidate(g, t, func(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+		containerMounts, err := mount.PidMountInfo(state.Pid)
+		if err != nil {
+			return err
+		}
+		inContainer := map[string]bool{}
+		for _, m := range containerMounts {
+			inContainer[m.Mountpoint] = true
+		}
+
+		var leaked []string
+		for _, mountpoint := range hostOnly {
+			if inContainer[mountpoint] {
+				leaked = append(leaked, mountpoint)
+			}
+		}
+		t.Ok(len(leaked) == 0, fmt.Sprintf("none of the %d host-only mounts is in the container", len(hostOnly)))
+		if len(leaked) > 0 {
+			t.YAML(map[string]interface{}{
+				"leaked": leaked,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fail(err.Error())
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// iffUp is the IFF_UP interface flag.
+const iffUp = 0x1
+
+// perNamespaceInterfaces are the fallback tunnel devices the kernel creates
+// in every new network namespace when their modules are loaded.
+var perNamespaceInterfaces = map[string]bool{
+	"lo":       true,
+	"erspan0":  true,
+	"gre0":     true,
+	"gretap0":  true,
+	"ip6_vti0": true,
+	"ip6gre0":  true,
+	"ip6tnl0":  true,
+	"ip_vti0":  true,
+	"sit0":     true,
+	"tunl0":    true,
+}
+
+// The runtime is expected to create a new network namespace holding only
+// the loopback interface.  Bringing the loopback interface up is common,
+// but not required by the runtime specification, so the test is skipped
+// when it is down.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific network namespace test")
+		return
+	}
+
+	hostInterfaces, err := ioutil.ReadDir("/sys/class/net")
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("network", ""); err != nil {
+		util.Fatal(err)
+	}
+	// the interfaces on the first line, the loopback flags on the second
+	g.SetProcessArgs([]string{"sh", "-c", "echo $(ls /sys/class/net) > /output; cat /sys/class/net/lo/flags >> /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+	lines := strings.SplitN(string(output), "\n", 2)
+
+	host := map[string]bool{}
+	for _, fi := range hostInterfaces {
+		host[fi.Name()] = true
+	}
+	var leaked []string
+	hasLoopback := false
+	for _, name := range strings.Fields(lines[0]) {
+		if name == "lo" {
+			hasLoopback = true
+		}
+		if host[name] && !perNamespaceInterfaces[name] {
+			leaked = append(leaked, name)
+		}
+	}
+
+	t.Ok(hasLoopback, "network namespace has a loopback interface")
+	t.Ok(len(leaked) == 0, "no host interfaces in the network namespace")
+	if len(leaked) > 0 {
+		t.YAML(map[string]interface{}{
+			"leaked": leaked,
+		})
+	}
+	if !hasLoopback || len(lines) < 2 {
+		return
+	}
+
+	flags, err := strconv.ParseUint(strings.TrimSpace(lines[1]), 0, 32)
+	if err != nil {
+		t.Fail(fmt.Sprintf("invalid loopback flags %q: %v", lines[1], err))
+		return
+	}
+	if flags&iffUp == 0 {
+		t.Skip(1, "the runtime does not bring the loopback interface up")
+	} else {
+		t.Pass("loopback interface is up")
+	}
+}
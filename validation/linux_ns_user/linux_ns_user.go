@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const (
+	// hostID is the unprivileged host uid and gid container root maps to.
+	hostID = 100000
+	// mappingSize is the number of mapped ids.
+	mappingSize = 65536
+)
+
+// userNamespaceGenerator returns a generator for a container in a new
+// user namespace, with container root mapped to hostID.
+func userNamespaceGenerator() (*generate.Generator, error) {
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		return nil, err
+	}
+	if err := g.AddOrReplaceLinuxNamespace("user", ""); err != nil {
+		return nil, err
+	}
+	g.AddLinuxUIDMapping(hostID, 0, mappingSize)
+	g.AddLinuxGIDMapping(hostID, 0, mappingSize)
+	return g, nil
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific user namespace test")
+		return
+	}
+	if _, err := os.Stat("/proc/self/uid_map"); err != nil {
+		t.Skip(1, "the kernel does not support user namespaces")
+		return
+	}
+
+	// runtimetest checks /proc/self/uid_map and /proc/self/gid_map
+	g, err := userNamespaceGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.AddAnnotation("TestName", "uid and gid mappings of a new user namespace")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// a file created by container root is owned by hostID on the host
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+	// the mapped container root needs to reach the rootfs
+	if err := os.Chmod(bundleDir, 0755); err != nil {
+		util.Fatal(err)
+	}
+
+	g, err = userNamespaceGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	dir := filepath.Join(bundleDir, g.Spec().Root.Path, "userns")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		util.Fatal(err)
+	}
+	if err := os.Chown(dir, hostID, hostID); err != nil {
+		util.Fatal(err)
+	}
+
+	g.SetProcessArgs([]string{"touch", "/userns/created"})
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, "created"))
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		util.Fatal(fmt.Errorf("cannot read the owner of %s", fi.Name()))
+	}
+	t.Ok(st.Uid == hostID && st.Gid == hostID, "file created by container root is owned by the mapped host ids")
+	t.YAML(map[string]uint32{
+		"expected uid": hostID,
+		"expected gid": hostID,
+		"actual uid":   st.Uid,
+		"actual gid":   st.Gid,
+	})
+}
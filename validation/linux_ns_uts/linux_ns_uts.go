@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const (
+	// containerHostname is the hostname configured for the container.
+	containerHostname = "runtime-tools-uts"
+	// changedHostname is the hostname the container changes to.
+	changedHostname = "runtime-tools-uts-changed"
+)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific UTS namespace test")
+		return
+	}
+
+	hostHostname, err := os.Hostname()
+	if err != nil {
+		util.Fatal(err)
+	}
+	t.Ok(hostHostname != containerHostname, "container hostname differs from the host's")
+
+	// runtimetest checks the configured hostname in a new UTS namespace
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("uts", ""); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.SetHostname(containerHostname); err != nil {
+		util.Fatal(err)
+	}
+	g.AddAnnotation("TestName", "hostname in a new UTS namespace")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// changing the hostname inside the container must not change the host's
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddOrReplaceLinuxNamespace("uts", ""); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.SetHostname(containerHostname); err != nil {
+		util.Fatal(err)
+	}
+	// sethostname needs CAP_SYS_ADMIN in the UTS namespace
+	if err := g.AddProcessCapability("CAP_SYS_ADMIN"); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", fmt.Sprintf("hostname %s && hostname > /output", changedHostname)})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	changed := strings.TrimSpace(string(output))
+	t.Ok(changed == changedHostname, "hostname can be changed inside the container")
+	if changed != changedHostname {
+		t.YAML(map[string]string{
+			"expected": changedHostname,
+			"actual":   changed,
+		})
+	}
+
+	actual, err := os.Hostname()
+	if err != nil {
+		util.Fatal(err)
+	}
+	t.Ok(actual == hostHostname, "changing the hostname inside the container does not change the host's")
+	if actual != hostHostname {
+		t.YAML(map[string]string{
+			"expected": hostHostname,
+			"actual":   actual,
+		})
+	}
+}
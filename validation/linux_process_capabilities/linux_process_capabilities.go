@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/validation/util"
+	"github.com/syndtr/gocapability/capability"
+)
+
+const ambientCap = "CAP_NET_BIND_SERVICE"
+
+// setCapabilities sets every capability set to caps, except for ambient.
+func setCapabilities(g *generate.Generator, caps []string, ambient []string) {
+	g.ClearProcessCapabilities()
+	g.Config.Process.Capabilities.Bounding = append([]string{}, caps...)
+	g.Config.Process.Capabilities.Effective = append([]string{}, caps...)
+	g.Config.Process.Capabilities.Inheritable = append([]string{}, caps...)
+	g.Config.Process.Capabilities.Permitted = append([]string{}, caps...)
+	g.Config.Process.Capabilities.Ambient = append([]string{}, ambient...)
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific process.capabilities test")
+		return
+	}
+
+	// an ambient capability which is in the permitted and inheritable sets
+	// is set in the container process
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	setCapabilities(g, []string{"CAP_CHOWN", ambientCap}, []string{ambientCap})
+	g.AddAnnotation("TestName", "ambient capability in the permitted and inheritable sets")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// an ambient capability which is not in the permitted and inheritable
+	// sets is either rejected by the runtime at create or not set in the
+	// container process
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	setCapabilities(g, []string{"CAP_CHOWN"}, []string{ambientCap})
+	g.SetProcessArgs([]string{"sh", "-c", "grep CapAmb /proc/self/status > /output"})
+	creating, created := false, false
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PreCreate: func(r *util.Runtime) error {
+			creating = true
+			return nil
+		},
+		PostCreate: func(r *util.Runtime) error {
+			created = true
+			return nil
+		},
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	if err != nil {
+		if !creating || created {
+			t.Fail(err.Error())
+			return
+		}
+		t.Pass("runtime rejects an ambient capability outside the permitted and inheritable sets")
+		t.YAML(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	output, err := ioutil.ReadFile(filepath.Join(bundleDir, g.Spec().Root.Path, "output"))
+	if err != nil {
+		util.Fatal(err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		util.Fatal(fmt.Errorf("unexpected CapAmb line %q", output))
+	}
+	ambient, err := strconv.ParseUint(fields[1], 16, 64)
+	if err != nil {
+		util.Fatal(err)
+	}
+	set := ambient&(1<<uint(capability.CAP_NET_BIND_SERVICE)) != 0
+	t.Ok(!set, "ambient capability outside the permitted and inheritable sets is not set")
+	if set {
+		t.Diagnosticf("expect: %s not in the ambient set, actual: CapAmb %s", ambientCap, fields[1])
+	}
+}
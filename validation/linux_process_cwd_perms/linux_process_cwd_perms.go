@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+const (
+	// privateCwd is the working directory of the process, owned by root
+	// with mode 0700.
+	privateCwd = "/private"
+	// processID is the uid and gid of the process, which cannot enter
+	// privateCwd.
+	processID = 1000
+)
+
+// The specification does not say what a runtime does when the process
+// user cannot enter process.cwd.  Either the runtime fails to create or
+// start the container, or it enters the directory before changing the
+// user, so that the process runs in process.cwd.  Starting the process in
+// another directory is inconsistent.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific process cwd test")
+		return
+	}
+
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+	// the process user needs to reach the rootfs
+	if err := os.Chmod(bundleDir, 0755); err != nil {
+		util.Fatal(err)
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	rootfs := filepath.Join(bundleDir, g.Spec().Root.Path)
+	if err := os.Mkdir(filepath.Join(rootfs, privateCwd), 0700); err != nil {
+		util.Fatal(err)
+	}
+	output := filepath.Join(rootfs, "output")
+	if err := ioutil.WriteFile(output, nil, 0666); err != nil {
+		util.Fatal(err)
+	}
+	if err := os.Chmod(output, 0666); err != nil {
+		util.Fatal(err)
+	}
+
+	g.SetProcessUID(processID)
+	g.SetProcessGID(processID)
+	// CAP_DAC_OVERRIDE would let the process into the directory anyway
+	if err := g.DropProcessCapability("CAP_DAC_OVERRIDE"); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessCwd(privateCwd)
+	g.SetProcessArgs([]string{"sh", "-c", "readlink /proc/self/cwd > /output"})
+	// only a create or start failure rejects the process
+	creating, started := false, false
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PreCreate: func(r *util.Runtime) error {
+			creating = true
+			return nil
+		},
+		PostStart: func(r *util.Runtime) error {
+			started = true
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	if err != nil {
+		if !creating || started {
+			util.Fatal(err)
+		}
+		t.Pass("the runtime fails to run a process which cannot enter its cwd")
+		t.YAML(map[string]string{
+			"outcome": "error",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		util.Fatal(err)
+	}
+	cwd := strings.TrimSpace(string(data))
+	t.Ok(cwd == privateCwd, "the process runs in the cwd it cannot enter itself")
+	t.YAML(map[string]string{
+		"outcome":  "started",
+		"expected": privateCwd,
+		"actual":   cwd,
+	})
+}
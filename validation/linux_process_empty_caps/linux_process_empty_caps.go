@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// capSets are the capability fields of /proc/<pid>/status.
+var capSets = []string{"CapInh", "CapPrm", "CapEff", "CapBnd", "CapAmb"}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific process.capabilities test")
+		return
+	}
+
+	// runtimetest checks that no capability is set
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.DropAllProcessCapabilities()
+	g.AddAnnotation("TestName", "empty capability sets")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// the kernel reports empty capability sets for the process
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.DropAllProcessCapabilities()
+	g.SetProcessArgs([]string{"sh", "-c", "grep ^Cap /proc/self/status > /output"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+	sets := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			sets[strings.TrimSuffix(fields[0], ":")] = fields[1]
+		}
+	}
+
+	for _, set := range capSets {
+		value, ok := sets[set]
+		if !ok {
+			if set == "CapAmb" {
+				t.Skip(1, "the kernel does not support ambient capabilities")
+				continue
+			}
+			t.Fail(fmt.Sprintf("no %s in /proc/self/status", set))
+			continue
+		}
+		mask, err := strconv.ParseUint(value, 16, 64)
+		if err != nil {
+			t.Fail(err.Error())
+			continue
+		}
+		t.Ok(mask == 0, fmt.Sprintf("%s is empty", set))
+		if mask != 0 {
+			t.Diagnosticf("expect: 0, actual: %s", value)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// sentinel is set in the environment of the runtime, but not in
+// process.env.
+const sentinel = "RUNTIME_TOOLS_ENV_SENTINEL"
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific process.env test")
+		return
+	}
+
+	// the runtime is started with the environment of this program
+	if err := os.Setenv(sentinel, "leaked"); err != nil {
+		util.Fatal(err)
+	}
+	defer os.Unsetenv(sentinel)
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.ClearProcessEnv()
+	if err := g.AddProcessEnv("PATH", "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddProcessEnv("TERM", "xterm"); err != nil {
+		util.Fatal(err)
+	}
+	g.AddAnnotation("org.opencontainers.runtimetest.env.absent", sentinel)
+
+	err = util.RuntimeInsideValidate(g, t, nil)
+	if err != nil {
+		t.Fail(err.Error())
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	rspecs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/specerror"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific process.args test")
+		return
+	}
+
+	// a bare command name must be found through PATH
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"touch", "/output"})
+	if err := g.AddProcessEnv("PATH", "/usr/local/sbin:/usr/local/bin:/bin"); err != nil {
+		util.Fatal(err)
+	}
+	_, err = util.RuntimeOutputValidate(g, nil)
+	util.SpecErrorOK(t, err == nil, specerror.NewError(specerror.ProcArgsOneEntryRequired, fmt.Errorf("args[0] is used with the same semantics as execvp's file"), rspecs.Version), err)
+
+	// a command which is not in PATH must fail
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	g, err = util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"runtime-tools-nonexistent-command"})
+	if err := g.AddProcessEnv("PATH", "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"); err != nil {
+		util.Fatal(err)
+	}
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	util.SpecErrorOK(t, err != nil, specerror.NewError(specerror.ProcImplement, fmt.Errorf("the runtime MUST generate an error if it cannot run the user-specified program"), rspecs.Version), nil)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/specerror"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// nofile is the soft RLIMIT_NOFILE of the container process.
+const nofile = 16
+
+// In a subshell, the shell fills file descriptors 3 to nofile-1 with
+// /dev/null, so that all nofile descriptors are in use, and then runs
+// head, which must fail to open a file with EMFILE.  With one descriptor
+// less in use, head must succeed.  The subshells exit with the exit code
+// of head, which are written to the first line of /output, followed by the
+// error messages from /stderr.  The shell redirects its own output only
+// when no descriptors are filled, since it needs a spare one to do so.
+var script = fmt.Sprintf(`exec 2>/stderr
+fill() {
+	i=3
+	while [ $i -lt $1 ]; do
+		eval "exec $i</dev/null"
+		i=$((i+1))
+	done
+}
+(fill %d; exec head -c 0 /etc/passwd)
+full=$?
+(fill %d; exec head -c 0 /etc/passwd)
+below=$?
+echo "$full $below" > /output
+cat /stderr >> /output
+`, nofile, nofile-1)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific rlimit test")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddProcessRlimits("RLIMIT_NOFILE", nofile, nofile); err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessArgs([]string{"sh", "-c", script})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	lines := strings.SplitN(string(output), "\n", 2)
+	codes := strings.Fields(lines[0])
+	if len(codes) != 2 {
+		t.Fail(fmt.Sprintf("unexpected output %q", output))
+		return
+	}
+	stderr := ""
+	if len(lines) == 2 {
+		stderr = lines[1]
+	}
+
+	emfile := codes[0] != "0" && strings.Contains(stderr, "Too many open files")
+	var detailedErr error
+	if !emfile {
+		detailedErr = fmt.Errorf("with %d open file descriptors and a soft limit of %d, head exited with %s: %q", nofile, nofile, codes[0], stderr)
+	}
+	util.SpecErrorOK(t, emfile, specerror.NewError(specerror.PosixProcRlimitsSoftMatchCur, fmt.Errorf("opening a file fails with EMFILE when %d file descriptors are open", nofile), g.Spec().Version), detailedErr)
+	t.Ok(codes[1] == "0", fmt.Sprintf("opening a file succeeds when %d file descriptors are open", nofile-1))
+}
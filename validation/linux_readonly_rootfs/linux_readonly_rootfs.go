@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+func contains(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific readonly root filesystem test")
+		return
+	}
+
+	// runtimetest checks the readonly root filesystem and the /tmp mount
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetRootReadonly(true)
+	if err := g.AddTmpfsMount("/tmp", []string{"nosuid", "nodev", "mode=1777"}); err != nil {
+		util.Fatal(err)
+	}
+	g.AddAnnotation("TestName", "readonly root filesystem with a writable /tmp")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// a shell in the container writes to / and /tmp.  The results are
+	// written to a bind mount, since the root filesystem itself is readonly.
+	bundleDir, err := util.PrepareBundle()
+	if err != nil {
+		util.Fatal(err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	resultsDir := filepath.Join(bundleDir, "results")
+	if err := os.Mkdir(resultsDir, 0777); err != nil {
+		util.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(bundleDir, g.Spec().Root.Path, "results"), 0755); err != nil {
+		util.Fatal(err)
+	}
+	g.AddMount(rspec.Mount{
+		Destination: "/results",
+		Type:        "bind",
+		Source:      resultsDir,
+		Options:     []string{"bind", "rw"},
+	})
+	g.SetProcessArgs([]string{"sh", "-c", `exec > /results/output
+if touch /readonly-test 2>/dev/null; then rm -f /readonly-test; echo root; fi
+if echo tmp > /tmp/readonly-test; then cat /tmp/readonly-test; rm -f /tmp/readonly-test; fi`})
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+		PostStart: func(r *util.Runtime) error {
+			return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	if err != nil {
+		t.Fail(err.Error())
+		return
+	}
+
+	output, err := ioutil.ReadFile(filepath.Join(resultsDir, "output"))
+	if err != nil {
+		util.Fatal(err)
+	}
+	written := strings.Fields(string(output))
+	t.Ok(!contains(written, "root"), "/ rejects writes on a readonly root filesystem")
+	t.Ok(contains(written, "tmp"), "/tmp accepts writes on a readonly root filesystem")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tap "github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/generate/seccomp"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// actionsAvail lists the seccomp actions supported by the kernel.
+const actionsAvail = "/proc/sys/kernel/seccomp/actions_avail"
+
+// actionAvailable returns true if the kernel supports a seccomp action.
+// Kernels older than Linux 4.14 have no actions_avail, but they support
+// killing the thread, the SCMP_ACT_KILL of older specifications.
+func actionAvailable(action string) bool {
+	data, err := ioutil.ReadFile(actionsAvail)
+	if os.IsNotExist(err) {
+		return action == "kill_thread"
+	} else if err != nil {
+		return false
+	}
+	for _, avail := range strings.Fields(string(data)) {
+		if avail == action {
+			return true
+		}
+	}
+	return false
+}
+
+// mkdir and mkdirat are blocked with a kill action, so a process calling
+// mkdir is killed by SIGSYS.  The process has a single thread, for which
+// SCMP_ACT_KILL_THREAD and SCMP_ACT_KILL_PROCESS both kill the whole
+// process.  Telling them apart needs a process with more threads, which
+// the rootfs does not have.
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific seccomp test")
+		return
+	}
+
+	for _, action := range []string{"kill_thread", "kill_process"} {
+		if !actionAvailable(action) {
+			t.Skip(1, fmt.Sprintf("the kernel does not support the seccomp action %s", action))
+			continue
+		}
+
+		bundleDir, err := util.PrepareBundle()
+		if err != nil {
+			util.Fatal(err)
+		}
+		defer os.RemoveAll(bundleDir)
+
+		g, err := util.GetDefaultGenerator()
+		if err != nil {
+			util.Fatal(err)
+		}
+		if err := g.SetDefaultSeccompAction("allow"); err != nil {
+			util.Fatal(err)
+		}
+		if err := g.SetSyscallAction(seccomp.SyscallOpts{Action: action, Syscall: "mkdir,mkdirat"}); err != nil {
+			util.Fatal(err)
+		}
+		// the subshell keeps the shell alive if it runs mkdir without forking
+		g.SetProcessArgs([]string{"sh", "-c", "(mkdir /seccomp-kill); echo $? > /output"})
+		err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+			Config:    g,
+			BundleDir: bundleDir,
+			Actions:   util.LifecycleActionCreate | util.LifecycleActionStart | util.LifecycleActionDelete,
+			PostStart: func(r *util.Runtime) error {
+				return util.WaitingForStatus(*r, util.LifecycleStatusStopped, time.Second*10, time.Second*1)
+			},
+		})
+		if err != nil {
+			t.Fail(err.Error())
+			continue
+		}
+
+		output, err := ioutil.ReadFile(filepath.Join(bundleDir, g.Spec().Root.Path, "output"))
+		if err != nil {
+			util.Fatal(err)
+		}
+		expected := 128 + int(syscall.SIGSYS)
+		status, err := strconv.Atoi(strings.TrimSpace(string(output)))
+		if err != nil {
+			t.Fail(fmt.Sprintf("invalid exit status %q: %v", output, err))
+			continue
+		}
+		t.Ok(status == expected, fmt.Sprintf("a syscall with the %s action kills the process with SIGSYS", action))
+		t.Diagnosticf("expect: exit status %d, actual: %d", expected, status)
+
+		_, err = os.Stat(filepath.Join(bundleDir, g.Spec().Root.Path, "seccomp-kill"))
+		t.Ok(os.IsNotExist(err), fmt.Sprintf("a syscall with the %s action is not run", action))
+	}
+}
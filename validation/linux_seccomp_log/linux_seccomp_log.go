@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	tap "github.com/mndrix/tap-go"
+	"github.com/opencontainers/runtime-tools/generate/seccomp"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+// actionsAvail lists the seccomp actions supported by the kernel.
+const actionsAvail = "/proc/sys/kernel/seccomp/actions_avail"
+
+// logAvailable returns true if the kernel supports SCMP_ACT_LOG, which was
+// added in Linux 4.14.
+func logAvailable() bool {
+	data, err := ioutil.ReadFile(actionsAvail)
+	if err != nil {
+		return false
+	}
+	for _, action := range strings.Fields(string(data)) {
+		if action == "log" {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific seccomp test")
+		return
+	}
+
+	if !logAvailable() {
+		t.Skip(2, "the kernel does not support SCMP_ACT_LOG")
+		return
+	}
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+
+	// getcwd is only logged, while mkdir and mkdirat are blocked
+	if err := g.SetDefaultSeccompAction("allow"); err != nil {
+		util.Fatal(err)
+	}
+	for _, rule := range []seccomp.SyscallOpts{
+		{Action: "log", Syscall: "getcwd"},
+		{Action: "errno", Syscall: "mkdir,mkdirat"},
+	} {
+		if err := g.SetSyscallAction(rule); err != nil {
+			util.Fatal(err)
+		}
+	}
+	g.SetProcessArgs([]string{"sh", "-c", "/bin/pwd > /output; if mkdir /seccomp-log; then echo mkdir >> /output; fi"})
+	output, err := util.RuntimeOutputValidate(g, nil)
+	if err != nil {
+		util.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	cwd := g.Spec().Process.Cwd
+	t.Ok(len(lines) > 0 && lines[0] == cwd, "a syscall with SCMP_ACT_LOG is permitted")
+	if len(lines) == 0 || lines[0] != cwd {
+		t.Diagnostic(fmt.Sprintf("expected the output of pwd to be %q, got %q", cwd, string(output)))
+	}
+
+	t.Ok(len(lines) < 2, "a syscall with SCMP_ACT_ERRNO is blocked")
+}
@@ -1,17 +1,78 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/mndrix/tap-go"
 	"github.com/opencontainers/runtime-tools/validation/util"
 )
 
+const ipForward = "net.ipv4.ip_forward"
+
 func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	if "linux" != runtime.GOOS {
+		t.Skip(1, "linux-specific sysctl test")
+		return
+	}
+
+	// a sysctl of the network namespace is checked by runtimetest through
+	// /proc/sys inside the container
 	g, err := util.GetDefaultGenerator()
 	if err != nil {
 		util.Fatal(err)
 	}
-	g.AddLinuxSysctl("net.ipv4.ip_forward", "1")
-	err = util.RuntimeInsideValidate(g, nil, nil)
+	if err := g.AddOrReplaceLinuxNamespace("network", ""); err != nil {
+		util.Fatal(err)
+	}
+	g.AddLinuxSysctl(ipForward, "1")
+	g.AddAnnotation("TestName", "sysctl in a new network namespace")
+	if err := util.RuntimeInsideValidate(g, t, nil); err != nil {
+		t.Fail(err.Error())
+	}
+
+	// a sysctl of the network namespace while sharing the host network
+	// namespace must be rejected.  The host value is reused so a runtime
+	// accepting the sysctl leaves the host unchanged.
+	hostValue, err := ioutil.ReadFile("/proc/sys/" + strings.Replace(ipForward, ".", "/", -1))
+	if err != nil {
+		util.Fatal(err)
+	}
+	g, err = util.GetDefaultGenerator()
 	if err != nil {
 		util.Fatal(err)
 	}
+	if err := g.RemoveLinuxNamespace("network"); err != nil {
+		util.Fatal(err)
+	}
+	g.AddLinuxSysctl(ipForward, strings.TrimSpace(string(hostValue)))
+
+	creating, created := false, false
+	err = util.RuntimeLifecycleValidate(util.LifecycleConfig{
+		Config:  g,
+		Actions: util.LifecycleActionCreate | util.LifecycleActionDelete,
+		PreCreate: func(r *util.Runtime) error {
+			creating = true
+			return nil
+		},
+		PostCreate: func(r *util.Runtime) error {
+			created = true
+			return nil
+		},
+	})
+	if !creating {
+		util.Fatal(err)
+	}
+	t.Ok(!created, fmt.Sprintf("runtime rejects %s without a network namespace at create", ipForward))
+	if err != nil && !created {
+		t.YAML(map[string]string{
+			"error": err.Error(),
+		})
+	}
 }
@@ -13,8 +13,12 @@ func main() {
 		util.Fatal(err)
 	}
 	g.SetProcessCwd("/test")
-	g.AddProcessEnv("testa", "valuea")
-	g.AddProcessEnv("testb", "123")
+	if err := g.AddProcessEnv("testa", "valuea"); err != nil {
+		util.Fatal(err)
+	}
+	if err := g.AddProcessEnv("testb", "123"); err != nil {
+		util.Fatal(err)
+	}
 
 	err = util.RuntimeInsideValidate(g, nil, func(path string) error {
 		pathName := filepath.Join(path, "test")
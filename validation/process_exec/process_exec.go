@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/opencontainers/runtime-tools/validation/util"
+)
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	g, err := util.GetDefaultGenerator()
+	if err != nil {
+		util.Fatal(err)
+	}
+	g.SetProcessCwd("/")
+	if err := g.AddProcessEnv("INIT", "1"); err != nil {
+		util.Fatal(err)
+	}
+
+	// the exec'ed process starts from the init process and diverges
+	execGenerator := generate.Generator{Config: &rspec.Spec{Process: g.Process()}}
+	execGenerator.SetProcessCwd("/tmp")
+	if err := execGenerator.AddProcessEnv("INIT", "0"); err != nil {
+		util.Fatal(err)
+	}
+	if err := execGenerator.AddProcessEnv("EXEC", "1"); err != nil {
+		util.Fatal(err)
+	}
+	if err := execGenerator.DropProcessCapabilityEffective("CAP_KILL"); err != nil {
+		util.Fatal(err)
+	}
+	execProcess := execGenerator.Process()
+	execProcess.Args = nil
+
+	g.AddAnnotation("TestName", "exec process environment")
+	err = util.RuntimeExecValidate(g, execProcess, t)
+	if err != nil {
+		util.Fatal(err)
+	}
+}
@@ -1,17 +1,47 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/mndrix/tap-go"
 	"github.com/opencontainers/runtime-tools/validation/util"
 )
 
-func main() {
+func testOOMScoreAdj(t *tap.T, oomScoreAdj *int) error {
 	g, err := util.GetDefaultGenerator()
 	if err != nil {
-		util.Fatal(err)
+		return err
 	}
-	g.SetProcessOOMScoreAdj(500)
-	err = util.RuntimeInsideValidate(g, nil, nil)
+
+	name := "unset oom_score_adj"
+	if oomScoreAdj != nil {
+		g.SetProcessOOMScoreAdj(*oomScoreAdj)
+		name = fmt.Sprintf("oom_score_adj %d", *oomScoreAdj)
+	}
+	g.AddAnnotation("TestName", fmt.Sprintf("check %s", name))
+	err = util.RuntimeInsideValidate(g, t, nil)
+	t.Ok(err == nil, fmt.Sprintf("container with %s runs", name))
 	if err != nil {
-		util.Fatal(err)
+		t.Diagnosticf("expect: err == nil, actual: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	t := tap.New()
+	t.Header(0)
+	defer t.AutoPlan()
+
+	positive, negative := 500, -500
+	for _, oomScoreAdj := range []*int{nil, &positive, &negative} {
+		// lowering oom_score_adj requires CAP_SYS_RESOURCE
+		if oomScoreAdj != nil && *oomScoreAdj < 0 && os.Geteuid() != 0 {
+			t.Skip(1, fmt.Sprintf("oom_score_adj %d requires CAP_SYS_RESOURCE", *oomScoreAdj))
+			continue
+		}
+		if err := testOOMScoreAdj(t, oomScoreAdj); err != nil {
+			t.Fail(err.Error())
+		}
 	}
 }
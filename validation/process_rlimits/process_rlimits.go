@@ -4,6 +4,7 @@ import (
 	"os"
 	"runtime"
 
+	rspecs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/validation/util"
 )
 
@@ -19,14 +20,19 @@ func main() {
 	}
 
 	var gigaBytes uint64 = 1024 * 1024 * 1024
-	g.AddProcessRlimits("RLIMIT_AS", 2*gigaBytes, 1*gigaBytes)
-	g.AddProcessRlimits("RLIMIT_CORE", 4*gigaBytes, 3*gigaBytes)
-	g.AddProcessRlimits("RLIMIT_DATA", 6*gigaBytes, 5*gigaBytes)
-	g.AddProcessRlimits("RLIMIT_FSIZE", 8*gigaBytes, 7*gigaBytes)
-	g.AddProcessRlimits("RLIMIT_STACK", 10*gigaBytes, 9*gigaBytes)
-
-	g.AddProcessRlimits("RLIMIT_CPU", 120, 60)       // seconds
-	g.AddProcessRlimits("RLIMIT_NOFILE", 4000, 3000) // number of files
+	for _, rlimit := range []rspecs.POSIXRlimit{
+		{Type: "RLIMIT_AS", Hard: 2 * gigaBytes, Soft: 1 * gigaBytes},
+		{Type: "RLIMIT_CORE", Hard: 4 * gigaBytes, Soft: 3 * gigaBytes},
+		{Type: "RLIMIT_DATA", Hard: 6 * gigaBytes, Soft: 5 * gigaBytes},
+		{Type: "RLIMIT_FSIZE", Hard: 8 * gigaBytes, Soft: 7 * gigaBytes},
+		{Type: "RLIMIT_STACK", Hard: 10 * gigaBytes, Soft: 9 * gigaBytes},
+		{Type: "RLIMIT_CPU", Hard: 120, Soft: 60},       // seconds
+		{Type: "RLIMIT_NOFILE", Hard: 4000, Soft: 3000}, // number of files
+	} {
+		if err := g.AddProcessRlimits(rlimit.Type, rlimit.Hard, rlimit.Soft); err != nil {
+			util.Fatal(err)
+		}
+	}
 	err = util.RuntimeInsideValidate(g, nil, nil)
 	if err != nil {
 		util.Fatal(err)
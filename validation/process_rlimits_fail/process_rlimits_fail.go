@@ -20,7 +20,12 @@ func main() {
 	if err != nil {
 		util.Fatal(err)
 	}
-	g.AddProcessRlimits("RLIMIT_TEST", 1024, 1024)
+	// AddProcessRlimits rejects unknown types, so add it directly
+	g.Config.Process.Rlimits = append(g.Config.Process.Rlimits, rspecs.POSIXRlimit{
+		Type: "RLIMIT_TEST",
+		Hard: 1024,
+		Soft: 1024,
+	})
 	err = util.RuntimeInsideValidate(g, nil, nil)
 	if err == nil {
 		util.Fatal(specerror.NewError(specerror.PosixProcRlimitsTypeGenError, fmt.Errorf("The runtime MUST generate an error for any values which cannot be mapped to a relevant kernel interface"), rspecs.Version))
@@ -18,6 +18,9 @@ func main() {
 		util.Fatal(err)
 	}
 	g.SetRootReadonly(true)
+	if err := g.AddTmpfsMount("/tmp", []string{"nosuid", "nodev", "mode=1777"}); err != nil {
+		util.Fatal(err)
+	}
 	err = util.RuntimeInsideValidate(g, nil, nil)
 	if err != nil {
 		util.Fatal(err)
@@ -181,6 +181,64 @@ func (r *Runtime) Kill(sig string) (err error) {
 	return execWithStderrFallbackToStdout(cmd)
 }
 
+// Exec runs an additional process, described by process, in a running
+// container and returns its standard output.  Exec is not part of the
+// command-line API, so this follows runc's 'exec --process' convention.
+func (r *Runtime) Exec(process *rspecs.Process) (stdout []byte, err error) {
+	if process == nil {
+		return nil, errors.New("cannot exec a nil process")
+	}
+
+	processPath := filepath.Join(r.bundleDir(), fmt.Sprintf("process-%s.json", uuid.NewV4().String()))
+	data, err := json.Marshal(process)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(processPath, data, 0600); err != nil {
+		return nil, err
+	}
+	defer os.Remove(processPath)
+
+	var args []string
+	args = append(args, "exec", "--process", processPath)
+	if r.ID != "" {
+		args = append(args, r.ID)
+	}
+
+	cmd := exec.Command(r.RuntimeCommand, args...)
+	stdout, err = cmd.Output()
+	if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) == 0 {
+		e.Stderr = stdout
+	}
+	return stdout, err
+}
+
+// Pause a container.  Pause is not part of the command-line API, so this
+// follows runc's convention.
+func (r *Runtime) Pause() (err error) {
+	var args []string
+	args = append(args, "pause")
+	if r.ID != "" {
+		args = append(args, r.ID)
+	}
+
+	cmd := exec.Command(r.RuntimeCommand, args...)
+	return execWithStderrFallbackToStdout(cmd)
+}
+
+// Resume a paused container.  Resume is not part of the command-line API,
+// so this follows runc's convention.
+func (r *Runtime) Resume() (err error) {
+	var args []string
+	args = append(args, "resume")
+	if r.ID != "" {
+		args = append(args, r.ID)
+	}
+
+	cmd := exec.Command(r.RuntimeCommand, args...)
+	return execWithStderrFallbackToStdout(cmd)
+}
+
 // Delete a container
 func (r *Runtime) Delete() (err error) {
 	var args []string
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"github.com/mndrix/tap-go"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/cgroups"
+)
+
+// ValidateLinuxCgroupsFreezer validates that the container is placed in a
+// freezer cgroup and that a container which has not been paused is thawed.
+func ValidateLinuxCgroupsFreezer(config *rspec.Spec, t *tap.T, state *rspec.State) error {
+	cg, err := cgroups.FindCgroup()
+	t.Ok((err == nil), "find freezer cgroup")
+	if err != nil {
+		t.Diagnostic(err.Error())
+		return nil
+	}
+
+	freezerState, err := cg.GetFreezerState(state.Pid, config.Linux.CgroupsPath)
+	t.Ok((err == nil), "get freezer cgroup state")
+	if err != nil {
+		t.Diagnostic(err.Error())
+		return nil
+	}
+
+	t.Ok(freezerState == cgroups.FreezerStateThawed, "freezer state is thawed")
+	t.Diagnosticf("expect: %s, actual: %s", cgroups.FreezerStateThawed, freezerState)
+
+	return nil
+}
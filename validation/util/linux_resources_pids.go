@@ -6,6 +6,9 @@ import (
 	"github.com/opencontainers/runtime-tools/cgroups"
 )
 
+// pidMaxLimit is the highest value of /proc/sys/kernel/pid_max.
+const pidMaxLimit = 1 << 22
+
 // ValidateLinuxResourcesPids validates linux.resources.pids.
 func ValidateLinuxResourcesPids(config *rspec.Spec, t *tap.T, state *rspec.State) error {
 	cg, err := cgroups.FindCgroup()
@@ -22,6 +25,14 @@ func ValidateLinuxResourcesPids(config *rspec.Spec, t *tap.T, state *rspec.State
 		return nil
 	}
 
+	if config.Linux.Resources.Pids.Limit < 0 {
+		// pids.max reads "max" when unlimited, some kernels report the
+		// highest possible pid number instead
+		t.Ok(lpd.Limit < 0 || lpd.Limit >= pidMaxLimit, "pids limit is unlimited")
+		t.Diagnosticf("expect: max, actual: %d", lpd.Limit)
+		return nil
+	}
+
 	t.Ok(lpd.Limit == config.Linux.Resources.Pids.Limit, "pids limit is set correctly")
 	t.Diagnosticf("expect: %d, actual: %d", config.Linux.Resources.Pids.Limit, lpd.Limit)
 
@@ -36,6 +36,10 @@ const (
 	LifecycleActionStart
 	// LifecycleActionDelete deletes a container
 	LifecycleActionDelete
+	// LifecycleActionPause pauses a started container
+	LifecycleActionPause
+	// LifecycleActionResume resumes a paused container
+	LifecycleActionResume
 )
 
 // LifecycleStatus follows https://github.com/opencontainers/runtime-spec/blob/master/runtime.md#state
@@ -50,26 +54,36 @@ const (
 	LifecycleStatusRunning
 	// LifecycleStatusStopped "stopped"
 	LifecycleStatusStopped
+	// LifecycleStatusPaused "paused", which is not defined by the spec
+	// but used by runtimes supporting pause
+	LifecycleStatusPaused
 )
 
+// statePaused is the additional state runtimes report for paused containers.
+const statePaused rspec.ContainerState = "paused"
+
 var lifecycleStatusMap = map[rspec.ContainerState]LifecycleStatus{
 	rspec.StateCreating: LifecycleStatusCreating,
 	rspec.StateCreated:  LifecycleStatusCreated,
 	rspec.StateRunning:  LifecycleStatusRunning,
 	rspec.StateStopped:  LifecycleStatusStopped,
+	statePaused:         LifecycleStatusPaused,
 }
 
 // LifecycleConfig includes
 // 1. Config to set the 'config.json'
 // 2. BundleDir to set the bundle directory
 // 3. Actions to define the default running lifecycles
-// 4. Four phases for user to add his/her own operations
+// 4. Phases for user to add his/her own operations
 type LifecycleConfig struct {
 	Config     *generate.Generator
 	BundleDir  string
 	Actions    LifecycleAction
 	PreCreate  func(runtime *Runtime) error
 	PostCreate func(runtime *Runtime) error
+	PostStart  func(runtime *Runtime) error
+	PostPause  func(runtime *Runtime) error
+	PostResume func(runtime *Runtime) error
 	PreDelete  func(runtime *Runtime) error
 	PostDelete func(runtime *Runtime) error
 }
@@ -147,9 +161,11 @@ func GetDefaultGenerator() (*generate.Generator, error) {
 	if err != nil {
 		return nil, err
 	}
-	g.SetRootPath(".")
+	if err := g.SetRootPath("."); err != nil {
+		return nil, err
+	}
 	g.SetProcessArgs([]string{"/runtimetest", "--path=/"})
-	return &g, err
+	return &g, nil
 }
 
 // WaitingForStatus waits an expected runtime status, return error if
@@ -303,6 +319,139 @@ func RuntimeOutsideValidate(g *generate.Generator, t *tap.T, f AfterFunc) error
 	return nil
 }
 
+// RuntimeOutputValidate runs the process of g in a container until it
+// stops, and returns what the process wrote to /output in the root
+// filesystem.  f, if not nil, is called with the bundle directory before
+// the container is created, e.g. to add files to the root filesystem.
+func RuntimeOutputValidate(g *generate.Generator, f PreFunc) ([]byte, error) {
+	bundleDir, err := PrepareBundle()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	if f != nil {
+		if err := f(bundleDir); err != nil {
+			return nil, err
+		}
+	}
+
+	err = RuntimeLifecycleValidate(LifecycleConfig{
+		Config:    g,
+		BundleDir: bundleDir,
+		Actions:   LifecycleActionCreate | LifecycleActionStart | LifecycleActionDelete,
+		PostStart: func(r *Runtime) error {
+			return WaitingForStatus(*r, LifecycleStatusStopped, time.Second*10, time.Second*1)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(filepath.Join(bundleDir, g.Spec().Root.Path, "output"))
+}
+
+// RuntimeExecValidate runs runtimetest as a second process inside a running
+// container.  The init process from g is replaced by a long-running sleep,
+// and runtimetest is exec'ed with execProcess, validating the exec'ed
+// process against a copy of the configuration where the process is
+// execProcess.  If execProcess.Args is empty, it defaults to running
+// runtimetest with --exec.
+func RuntimeExecValidate(g *generate.Generator, execProcess *rspec.Process, t *tap.T) error {
+	if execProcess == nil {
+		return errors.New("cannot exec a nil process")
+	}
+
+	bundleDir, err := PrepareBundle()
+	if err != nil {
+		return err
+	}
+
+	r, err := NewRuntime(RuntimeCommand, bundleDir)
+	if err != nil {
+		os.RemoveAll(bundleDir)
+		return err
+	}
+	defer r.Clean(true, true)
+
+	process := *execProcess
+	if len(process.Args) == 0 {
+		process.Args = []string{"/runtimetest", "--path=/exec", "--exec"}
+	}
+
+	// runtimetest compares the exec'ed process with exec/config.json
+	execSpec := *g.Config
+	execSpec.Process = &process
+	execGenerator := generate.Generator{Config: &execSpec}
+	if err := os.Mkdir(filepath.Join(r.BundleDir, "exec"), 0755); err != nil {
+		return err
+	}
+	err = execGenerator.SaveToFile(filepath.Join(r.BundleDir, "exec", "config.json"), generate.ExportOptions{})
+	if err != nil {
+		return err
+	}
+
+	g.SetProcessArgs([]string{"sleep", "30"})
+	err = r.SetConfig(g)
+	if err != nil {
+		return err
+	}
+	err = fileutils.CopyFile("runtimetest", filepath.Join(r.BundleDir, "runtimetest"))
+	if err != nil {
+		return err
+	}
+
+	r.SetID(uuid.NewV4().String())
+	err = r.Create()
+	if err != nil {
+		os.Stderr.WriteString("failed to create the container\n")
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+			os.Stderr.Write(e.Stderr)
+		}
+		return err
+	}
+
+	err = r.Start()
+	if err != nil {
+		os.Stderr.WriteString("failed to start the container\n")
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+			os.Stderr.Write(e.Stderr)
+		}
+		return err
+	}
+
+	err = WaitingForStatus(r, LifecycleStatusRunning, 10*time.Second, 1*time.Second)
+	if err != nil {
+		return err
+	}
+
+	stdout, err := r.Exec(&process)
+	if t != nil {
+		diagnostic := map[string]string{
+			"stdout": string(stdout),
+		}
+		if err != nil {
+			diagnostic["error"] = fmt.Sprintf("%v", err)
+			if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+				diagnostic["stderr"] = string(e.Stderr)
+			}
+		}
+		t.YAML(diagnostic)
+		t.Ok(err == nil && !strings.Contains(string(stdout), "not ok"), g.Config.Annotations["TestName"])
+		return nil
+	}
+
+	if err != nil {
+		os.Stderr.WriteString("failed to exec the process\n")
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+			os.Stderr.Write(e.Stderr)
+		}
+		return err
+	}
+	os.Stdout.Write(stdout)
+	return nil
+}
+
 // RuntimeLifecycleValidate validates runtime lifecycle.
 func RuntimeLifecycleValidate(config LifecycleConfig) error {
 	var bundleDir string
@@ -336,6 +485,10 @@ func RuntimeLifecycleValidate(config LifecycleConfig) error {
 	}
 
 	if config.Actions&LifecycleActionCreate != 0 {
+		// unless PreCreate chose one, create the container with a fresh ID
+		if r.ID == "" {
+			r.SetID(uuid.NewV4().String())
+		}
 		err := r.Create()
 		if err != nil {
 			os.Stderr.WriteString("failed to create the container\n")
@@ -379,6 +532,52 @@ func RuntimeLifecycleValidate(config LifecycleConfig) error {
 		}
 	}
 
+	if config.PostStart != nil {
+		if err := config.PostStart(&r); err != nil {
+			return err
+		}
+	}
+
+	if config.Actions&LifecycleActionPause != 0 {
+		err := r.Pause()
+		if err != nil {
+			os.Stderr.WriteString("failed to pause the container\n")
+			if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+				os.Stderr.Write(e.Stderr)
+			}
+			return err
+		}
+		if err := WaitingForStatus(r, LifecycleStatusPaused, time.Second*10, time.Second/10); err != nil {
+			return err
+		}
+	}
+
+	if config.PostPause != nil {
+		if err := config.PostPause(&r); err != nil {
+			return err
+		}
+	}
+
+	if config.Actions&LifecycleActionResume != 0 {
+		err := r.Resume()
+		if err != nil {
+			os.Stderr.WriteString("failed to resume the container\n")
+			if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+				os.Stderr.Write(e.Stderr)
+			}
+			return err
+		}
+		if err := WaitingForStatus(r, LifecycleStatusRunning|LifecycleStatusStopped, time.Second*10, time.Second/10); err != nil {
+			return err
+		}
+	}
+
+	if config.PostResume != nil {
+		if err := config.PostResume(&r); err != nil {
+			return err
+		}
+	}
+
 	if config.PreDelete != nil {
 		if err := config.PreDelete(&r); err != nil {
 			return err